@@ -10,6 +10,7 @@ require (
 	github.com/idelchi/godyl v0.1.6-beta.0.20250923201746-b8b9627f302e
 	github.com/spf13/cobra v1.10.1
 	golang.org/x/sys v0.36.0
+	mvdan.cc/sh/v3 v3.12.0
 )
 
 require (
@@ -21,5 +22,4 @@ require (
 	github.com/showa-93/go-mask v0.6.2 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
 	github.com/stretchr/testify v1.11.1 // indirect
-	mvdan.cc/sh/v3 v3.12.0 // indirect
 )
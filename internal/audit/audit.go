@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// Entry is a single use of a profile.
+type Entry struct {
+	// Time is when the profile was used.
+	Time time.Time `json:"time"`
+	// User is the name of the user.
+	User string `json:"user"`
+	// Host is the hostname of the machine.
+	Host string `json:"host"`
+	// Config is the absolute path of the config file.
+	Config string `json:"config"`
+	// Hash is the SHA-256 hash of the content of the config file.
+	Hash string `json:"hash"`
+	// Profile is the name of the profile.
+	Profile string `json:"profile"`
+	// Overlays are the profiles overlaid on top of the profile.
+	Overlays []string `json:"overlays,omitempty"`
+	// Command is the envprof subcommand, e.g. "exec".
+	Command string `json:"command"`
+	// Args is the command line run with the profile, for commands running one.
+	Args []string `json:"args,omitempty"`
+}
+
+// Stamp returns the entry with the time, user and host of the current process filled in.
+func (e Entry) Stamp() Entry {
+	e.Time = time.Now().UTC()
+	e.Host, _ = os.Hostname()
+
+	if current, err := user.Current(); err == nil {
+		e.User = current.Username
+	} else {
+		e.User = os.Getenv("USER") + os.Getenv("USERNAME")
+	}
+
+	return e
+}
+
+// Append appends the entry to the log at path as a single JSON line, creating the log and its directory if needed.
+func Append(path string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	//nolint:mnd	// Private directory for the log.
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	//nolint:mnd	// Private log file.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// Read returns the entries of the log at path recorded at or after since, in the order they were recorded.
+// A missing log has no entries.
+func Read(path string, since time.Time) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var entries []Entry
+
+	scanner := bufio.NewScanner(file)
+
+	//nolint:mnd	// Allow long command lines.
+	scanner.Buffer(nil, 1<<20)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
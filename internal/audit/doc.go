@@ -0,0 +1,3 @@
+// Package audit records the use of profiles in an append-only log of JSON lines.
+// Entries hold who used which profile, when and how, but never the values of the environment.
+package audit
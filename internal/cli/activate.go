@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/idelchi/envprof/internal/audit"
+	"github.com/idelchi/envprof/internal/environment"
+	"github.com/idelchi/envprof/internal/envprof"
+	"github.com/idelchi/envprof/internal/profiles"
+)
+
+// Expires is the variable holding the time at which a time-limited profile expires, in RFC 3339 format.
+const Expires = "ENVPROF_EXPIRES_AT"
+
+// Activation is a profile selected for use.
+type Activation struct {
+	// Profiles are all loaded profiles.
+	Profiles profiles.Profiles
+	// Name is the name of the selected profile.
+	Name string
+	// Environment is the resolved environment, including the overlays.
+	Environment environment.Environment
+	// TTL is the shortest time-to-live of the contributing profiles, or 0 if unlimited.
+	TTL time.Duration
+	// Expires is the time at which the activation expires, or the zero time if unlimited.
+	Expires time.Time
+
+	envprof *envprof.EnvProf
+	entry   audit.Entry
+}
+
+// Activate loads and resolves the selected profile, requiring confirmation if protected profiles are involved.
+// For time-limited profiles, the environment holds the expiry time in ENVPROF_EXPIRES_AT.
+// The use of the profile is not recorded in the audit log until Record is called.
+func Activate(options *Options) (Activation, error) {
+	ep, prof, name, steps, err := loadPlan(options)
+	if err != nil {
+		return Activation{}, err
+	}
+
+	if err := Confirm(options, prof, steps); err != nil {
+		return Activation{}, err
+	}
+
+	ttl, err := prof.TTL(steps)
+	if err != nil {
+		return Activation{}, err
+	}
+
+	env, err := prof.Environment(name, steps)
+	if err != nil {
+		return Activation{}, err
+	}
+
+	activation := Activation{
+		Profiles:    prof,
+		Name:        name,
+		Environment: env,
+		TTL:         ttl,
+		envprof:     ep,
+		entry:       audit.Entry{Profile: name, Overlays: options.Overlay, Command: options.Command},
+	}
+
+	if ttl > 0 {
+		activation.Expires = time.Now().Add(ttl)
+
+		if err := activation.Environment.Env.AddPair(Expires, activation.Expires.UTC().Format(time.RFC3339)); err != nil {
+			return Activation{}, err
+		}
+	}
+
+	return activation, nil
+}
+
+// Record records the use of the profile in the audit log, along with the command line run with it, if any.
+func (a Activation) Record(commandLine []string) error {
+	entry := a.entry
+	entry.Args = commandLine
+
+	return Record(a.envprof, entry)
+}
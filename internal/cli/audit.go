@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/audit"
+	"github.com/idelchi/envprof/internal/envprof"
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// AuditLog is the variable holding the path of the audit log, taking precedence over the 'audit' setting.
+const AuditLog = "ENVPROF_AUDIT_LOG"
+
+// Record appends the entry to the audit log, if enabled, filling in the details of the current process
+// and of the config file.
+func Record(ep *envprof.EnvProf, entry audit.Entry) error {
+	path, err := auditLog(ep)
+	if err != nil || path == "" {
+		return err
+	}
+
+	entry = entry.Stamp()
+	entry.Hash = ep.Hash()
+
+	if entry.Config, err = filepath.Abs(ep.File().Path()); err != nil {
+		return err
+	}
+
+	if err := audit.Append(path, entry); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+
+	return nil
+}
+
+// auditLog returns the path of the audit log from ENVPROF_AUDIT_LOG or the 'audit' setting of the config file,
+// or an empty string if disabled. The config file is not required if ENVPROF_AUDIT_LOG is set.
+func auditLog(ep *envprof.EnvProf) (string, error) {
+	if path := env.FromEnv().Get(AuditLog); path != "" {
+		return filepath.Abs(path)
+	}
+
+	if ep == nil {
+		return "", nil
+	}
+
+	return ep.Settings().AuditLog(ep.File().Dir())
+}
+
+// Audit returns the cobra command for querying the audit log.
+//
+//nolint:funlen	// Long help text and output formats.
+func Audit(options *Options) *cobra.Command {
+	var (
+		since  = "24h"
+		format = "text"
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit [profile...]",
+		Short: "Show the audit log of profile uses",
+		Long: heredoc.Docf(`
+			Show the entries of the audit log recorded since the given time, optionally only for the given profiles.
+
+			The audit log is enabled by setting its path in %s, or with the 'audit' setting of the config file.
+			Each command resolving a profile then appends an entry with the time, user, host, config file and its hash,
+			profile, overlays, subcommand and, for 'exec' and 'each', the command line.
+			Values of the environment are never recorded.
+
+			--since accepts a duration (e.g. 24h), a date (2006-01-02) or a timestamp (RFC 3339).
+		`, AuditLog),
+		Example: heredoc.Doc(`
+			# Show who used 'prod' in the last week
+			envprof audit --since 168h prod
+
+			# Show all entries since a date, as JSON lines
+			envprof audit --since 2025-01-01 --format json
+		`),
+		RunE: func(_ *cobra.Command, args []string) error {
+			start, err := parseSince(since, time.Now())
+			if err != nil {
+				return err
+			}
+
+			// The config file only provides the 'audit' setting.
+			ep, err := LoadEnvProf(options)
+			if err != nil && env.FromEnv().Get(AuditLog) == "" {
+				return err
+			}
+
+			path, err := auditLog(ep)
+			if err != nil {
+				return err
+			}
+
+			if path == "" {
+				return fmt.Errorf("audit log is not enabled, set %s or the 'audit' setting", AuditLog)
+			}
+
+			entries, err := audit.Read(path, start)
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 0 {
+				entries = slices.DeleteFunc(entries, func(entry audit.Entry) bool {
+					return !slices.Contains(args, entry.Profile)
+				})
+			}
+
+			var builder strings.Builder
+
+			switch format {
+			case "text":
+				//nolint:mnd 	// minwidth, tabwidth, padding, padchar, flags
+				writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+
+				_, _ = fmt.Fprintln(writer, "TIME\tUSER\tHOST\tPROFILE\tOVERLAYS\tCOMMAND")
+
+				for _, entry := range entries {
+					command := entry.Command
+					if len(entry.Args) > 0 {
+						command += " -- " + quoteArgs(entry.Args)
+					}
+
+					_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+						entry.Time.Local().Format(time.DateTime),
+						entry.User,
+						entry.Host,
+						entry.Profile,
+						strings.Join(entry.Overlays, ","),
+						command,
+					)
+				}
+
+				_ = writer.Flush()
+			case "json":
+				for _, entry := range entries {
+					data, err := json.Marshal(entry)
+					if err != nil {
+						return err
+					}
+
+					builder.Write(append(data, '\n'))
+				}
+			default:
+				return fmt.Errorf("unsupported format %q, must be one of text or json", format)
+			}
+
+			//nolint:forbidigo	// Command prints out to the console.
+			fmt.Print(builder.String())
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVarP(&since, "since", "s", since, "Show entries since a duration ago, date or timestamp")
+	cmd.Flags().StringVarP(&format, "format", "F", format, "Output format (text or json)")
+
+	return cmd
+}
+
+// parseSince parses a duration before now, a date or an RFC 3339 timestamp.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(since); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, since, time.Local); err == nil {
+		return date, nil
+	}
+
+	if timestamp, err := time.Parse(time.RFC3339, since); err == nil {
+		return timestamp, nil
+	}
+
+	return time.Time{}, errors.New("invalid '--since' " + strconv.Quote(since) +
+		", must be a duration (24h), a date (2006-01-02) or a timestamp (RFC 3339)")
+}
+
+// quoteArgs joins the arguments, quoting those that are empty or contain whitespace or quotes.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+
+		quoted[i] = arg
+	}
+
+	return strings.Join(quoted, " ")
+}
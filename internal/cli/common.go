@@ -6,10 +6,10 @@ import (
 	"io"
 	"os"
 	"slices"
-	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/idelchi/envprof/internal/audit"
 	"github.com/idelchi/envprof/internal/environment"
 	"github.com/idelchi/envprof/internal/envprof"
 	"github.com/idelchi/envprof/internal/profiles"
@@ -52,26 +52,45 @@ func LoadProfiles(options *Options) (profiles.Profiles, error) {
 	return envprof.Profiles(), nil
 }
 
-// LoadPlan returns the plan for the specified profile.
+// LoadPlan returns the plan for the specified profile, recording its use in the audit log.
 func LoadPlan(options *Options) (profiles.Profiles, string, step.Steps, error) {
-	envprof, err := LoadEnvProf(options)
+	envprof, profiles, profile, steps, err := loadPlan(options)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
+	if err := recordUse(envprof, options, profile); err != nil {
+		return nil, "", nil, err
+	}
+
+	return profiles, profile, steps, nil
+}
+
+// recordUse records the use of the profile with the overlays of the options in the audit log.
+func recordUse(envprof *envprof.EnvProf, options *Options, profile string) error {
+	return Record(envprof, audit.Entry{Profile: profile, Overlays: options.Overlay, Command: options.Command})
+}
+
+// loadPlan returns the loaded file and the plan for the specified profile.
+func loadPlan(options *Options) (*envprof.EnvProf, profiles.Profiles, string, step.Steps, error) {
+	envprof, err := LoadEnvProf(options)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+
 	profile, err := envprof.GetOrDefault(options.Profile)
 	if err != nil {
-		return nil, "", nil, err
+		return nil, nil, "", nil, err
 	}
 
 	profiles := envprof.Profiles()
 
 	steps, err := profiles.Plan(profile, options.Overlay...)
 	if err != nil {
-		return nil, "", nil, err
+		return nil, nil, "", nil, err
 	}
 
-	return profiles, profile, steps, nil
+	return envprof, profiles, profile, steps, nil
 }
 
 // LoadProfile returns the loaded and resolved profile.
@@ -116,25 +135,41 @@ func IsStdinPiped() (bool, error) {
 	return isPipe, nil
 }
 
-// Read returns stdin as a string, trimming the trailing newline.
-func Read() ([]string, error) {
-	bytes, err := io.ReadAll(os.Stdin)
+// IsTerminal checks if the file is a terminal.
+func IsTerminal(file *os.File) bool {
+	stat, err := file.Stat()
 	if err != nil {
-		return nil, err
+		return false
 	}
 
-	input := strings.Split(strings.TrimSuffix(string(bytes), "\n"), " ")
+	return stat.Mode()&os.ModeCharDevice != 0
+}
 
-	// trim each element, then drop empties
-	for i := range input {
-		input[i] = strings.TrimSpace(input[i])
+// UseColor resolves a color mode ("auto", "always" or "never") for output to the given file.
+// In "auto" mode, colors are used for terminals unless NO_COLOR is set or TERM is "dumb".
+func UseColor(mode string, file *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		_, noColor := os.LookupEnv("NO_COLOR")
+
+		return IsTerminal(file) && !noColor && os.Getenv("TERM") != "dumb", nil
+	default:
+		return false, fmt.Errorf("unsupported color mode %q, must be one of auto, always or never", mode)
 	}
+}
 
-	input = slices.DeleteFunc(input, func(s string) bool {
-		return s == ""
-	})
+// Read returns stdin as a string.
+func Read() (string, error) {
+	bytes, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
 
-	return input, nil
+	return string(bytes), nil
 }
 
 // UnknownSubcommandAction handles unknown cobra subcommands.
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/audit"
+	"github.com/idelchi/envprof/internal/environment"
+)
+
+// Compare returns the cobra command for comparing several profiles side by side.
+func Compare(options *Options) *cobra.Command {
+	var (
+		all         bool
+		differences bool
+		format      = "text"
+	)
+
+	cmd := &cobra.Command{
+		Use:   "compare [source...]",
+		Short: "Compare profiles in a key-by-profile matrix",
+		Long: heredoc.Doc(`
+			Compare several profiles (or other sources, see 'diff') side by side.
+
+			Each key is marked with its status across the profiles:
+			 =   the key is identical everywhere
+			 ~   the key is present everywhere, with differing values
+			 !   the key is missing in some profiles
+
+			Output formats are text, markdown and json.
+		`),
+		Example: heredoc.Doc(`
+			# Compare three profiles
+			envprof compare dev staging prod
+
+			# Compare all profiles, showing only keys that differ
+			envprof compare --all --differences
+
+			# Render a Markdown table
+			envprof compare --all --format markdown
+		`),
+		Aliases: []string{"cmp"},
+		RunE: func(_ *cobra.Command, args []string) error {
+			switch {
+			case all && len(args) > 0:
+				return errors.New("'--all' and [source...] are incompatible and may not be used together")
+			case !all && len(args) == 0:
+				return errors.New("at least one [source] or '--all' is required")
+			}
+
+			var environments []environment.Environment
+
+			if all {
+				ep, err := LoadEnvProf(options)
+				if err != nil {
+					return err
+				}
+
+				if environments, err = ep.Profiles().Environments(); err != nil {
+					return err
+				}
+
+				for _, env := range environments {
+					if err := Record(ep, audit.Entry{Profile: env.Name, Command: options.Command}); err != nil {
+						return err
+					}
+				}
+			}
+
+			for _, arg := range args {
+				env, err := LoadSource(options, arg)
+				if err != nil {
+					return err
+				}
+
+				env.Name = arg
+
+				environments = append(environments, env)
+			}
+
+			matrix := environment.Compare(environments...)
+
+			if differences {
+				matrix = matrix.Differences()
+			}
+
+			var output string
+
+			switch format {
+			case "text":
+				output = matrix.Text()
+			case "markdown":
+				output = matrix.Markdown()
+			case "json":
+				var err error
+
+				if output, err = matrix.JSON(); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported format %q, must be one of text, markdown or json", format)
+			}
+
+			//nolint:forbidigo	// Command prints out to the console.
+			fmt.Println(output)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "Compare all profiles")
+	cmd.Flags().BoolVarP(&differences, "differences", "d", false, "Show only keys that differ or are missing")
+	cmd.Flags().StringVarP(&format, "format", "F", format, "Output format (text, markdown or json)")
+
+	return cmd
+}
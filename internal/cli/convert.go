@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/edit"
+	"github.com/idelchi/envprof/internal/environment"
+	"github.com/idelchi/envprof/internal/envprof"
+	"github.com/idelchi/envprof/pkg/atomicfile"
+)
+
+// Convert returns the cobra command for converting the config file to another format.
+//
+//nolint:funlen	// Long help text and flags.
+func Convert(options *Options) *cobra.Command {
+	var (
+		to     string
+		source bool
+		output string
+	)
+
+	formats := []edit.Format{edit.YAML, edit.TOML, edit.JSON}
+
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert the config file to another format",
+		Long: heredoc.Doc(`
+			Convert the config file to YAML, TOML or JSON, printing the result or writing it with --output.
+
+			The order of profiles and keys is kept, as are comments and env sequences where the target supports them.
+			TOML has no sequences or null values, so env sequences become mappings there, null env values become
+			empty strings (as which they are exported), and other null values are left out.
+			Nested env values (mappings, or sequences containing them) become the JSON strings they are exported as.
+			JSON carries no comments.
+
+			The converted content must load as the same profiles and settings, or nothing is written.
+
+			The rendered file is converted by default. With --source, the unrendered template source is converted
+			instead, which only works if the templates do not span YAML or TOML syntax.
+		`),
+		Example: heredoc.Doc(`
+			# Convert envprof.yaml to envprof.toml
+			envprof convert --to toml --output envprof.toml
+
+			# Keep the templates
+			envprof convert --to toml --source
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			target := edit.Format(to)
+			if !slices.Contains(formats, target) {
+				return fmt.Errorf("unsupported format %q, must be one of %v", to, formats)
+			}
+
+			ep, err := LoadEnvProf(options)
+			if err != nil {
+				return err
+			}
+
+			data, err := ep.File().Read()
+			if err != nil {
+				return err
+			}
+
+			if !source {
+				if data, err = ep.Render(data); err != nil {
+					return err
+				}
+			}
+
+			converted, err := edit.Convert(data, edit.Format(ep.Format()), target)
+			if err != nil {
+				if source {
+					return fmt.Errorf("converting the template source: %w", err)
+				}
+
+				return fmt.Errorf("converting %q: %w", ep.File().Path(), err)
+			}
+
+			if err := sameContent(data, ep.Format(), converted, target); err != nil {
+				return fmt.Errorf("converted content is invalid, not writing it: %w", err)
+			}
+
+			if output == "" {
+				_, err := os.Stdout.Write(converted)
+
+				return err
+			}
+
+			return atomicfile.Write(output, converted, environment.DefaultMode)
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVarP(&to, "to", "t", "", fmt.Sprintf("Target format, one of %v", formats))
+	cmd.Flags().BoolVarP(&source, "source", "s", false, "Convert the unrendered template source")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write to instead of stdout")
+
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+// sameContent checks that the converted content loads as the same profiles and settings as the original.
+// JSON is loaded as YAML, of which it is a subset.
+func sameContent(data []byte, format envprof.Type, converted []byte, target edit.Format) error {
+	if target == edit.JSON {
+		target = edit.YAML
+	}
+
+	profiles, settings, err := envprof.Unmarshal(data, format)
+	if err != nil {
+		return err
+	}
+
+	convertedProfiles, convertedSettings, err := envprof.Unmarshal(converted, envprof.Type(target))
+	if err != nil {
+		return err
+	}
+
+	if settings != convertedSettings {
+		return errors.New("settings differ")
+	}
+
+	return profiles.Mismatch(convertedProfiles)
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
@@ -10,61 +11,154 @@ import (
 )
 
 // Diff returns the cobra command for diffing profiles.
+//
+//nolint:funlen	// Long help text and flags.
 func Diff(options *Options) *cobra.Command {
+	var (
+		format  = string(environment.Simple)
+		color   = "auto"
+		quiet   bool
+		include []string
+		exclude []string
+	)
+
 	cmd := &cobra.Command{
-		Use:   "diff <profile>",
-		Short: "Diff profiles",
+		Use:   "diff [source] <source>",
+		Short: "Diff profiles and other sources",
 		Long: heredoc.Doc(`
-			Compare the specified profile with the currently loaded profile.
+			Compare the specified source with the currently loaded profile,
+			or two sources with each other.
+
+			Sources can be:
+			 [profile:]<name>         a profile of the loaded file, with overlays applied
+			 dotenv:<path>            a dotenv file
+			 environ:                 the environment of the current process
+			 file:<path>[#<profile>]  a profile of another file, with overlays applied (default profile if omitted)
+			 git:<rev>[#<profile>]    a profile of the loaded file at a git revision, with overlays applied
+			                          (default profile if omitted), reading dotenv extends as of the revision
 
-			Outputs changes in a diff-like format:
+			Outputs changes in a diff-like format (--format simple):
 			 - KEY="VALUE"   means the key was removed
 			 + KEY="VALUE"   means the key was added
 			 ~ KEY: "OLD" -> "NEW"   means the key changed
+
+			Other formats are unified, side-by-side and json.
+
+			Exits with 0 if the sources are equal, 1 if they differ, and 2 on errors.
+		`),
+		Example: heredoc.Doc(`
+			# Compare 'dev' with 'prod'
+			envprof --profile dev diff prod
+
+			# Compare 'prod' with a dotenv file
+			envprof diff prod dotenv:.env
+
+			# Compare the current process environment with 'dev'
+			envprof diff environ: dev
+
+			# Review the effect of changes to 'prod' since the last commit
+			envprof diff git:HEAD#prod prod
+
+			# Unified diff of the database settings only
+			envprof diff dev prod --format unified --include 'DB_*'
+
+			# Only check whether two profiles differ
+			envprof diff dev prod --quiet || echo "different"
 		`),
 		Args: func(cmd *cobra.Command, args []string) error {
-			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
-				return fmt.Errorf(
-					"%q requires a <profile> as it's only positional argument, received %d arguments: %v",
+			if err := cobra.RangeArgs(1, 2)(cmd, args); err != nil {
+				return ExitError{Code: 2, Err: fmt.Errorf(
+					"%q requires one or two <source> positional arguments, received %d arguments: %v",
 					cmd.Name(),
 					len(args),
 					args,
-				)
+				)}
 			}
 
 			return nil
 		},
 		RunE: func(_ *cobra.Command, args []string) error {
-			env1, err := LoadProfile(options)
-			if err != nil {
-				return err
+			equal, err := diff(options, args, environment.RenderOptions{Format: environment.DiffFormat(format)},
+				color, quiet, include, exclude)
+
+			switch {
+			case err != nil:
+				return ExitError{Code: 2, Err: err}
+			case !equal:
+				return ExitError{Code: 1}
+			default:
+				return nil
 			}
+		},
+	}
 
-			options.Profile = args[0]
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return ExitError{Code: 2, Err: err}
+	})
 
-			env2, err := LoadProfile(options)
-			if err != nil {
-				return err
-			}
+	cmd.Flags().SortFlags = false
 
-			diff := environment.Diffs(env1.Env, env2.Env)
+	cmd.Flags().
+		StringVarP(&format, "format", "F", format, "Output format (simple, unified, side-by-side or json)")
+	cmd.Flags().StringVar(&color, "color", color, "Colorize the output (auto, always or never)")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print nothing, only report differences with the exit code")
+	cmd.Flags().StringSliceVarP(&include, "include", "i", nil, "Only compare keys matching any of the patterns")
+	cmd.Flags().StringSliceVarP(&exclude, "exclude", "x", nil, "Do not compare keys matching any of the patterns")
 
-			if diff.Equal() {
-				//nolint:forbidigo	// Command prints out to the console.
-				fmt.Println("No differences found.")
+	return cmd
+}
 
-				return nil
-			}
+// diff compares the sources given as arguments, printing the differences unless quiet.
+// It reports whether the sources are equal.
+func diff(
+	options *Options,
+	args []string,
+	render environment.RenderOptions,
+	color string,
+	quiet bool,
+	include, exclude []string,
+) (bool, error) {
+	var err error
 
-			if err := diff.Render(env1.Name, env2.Name); err != nil {
-				return err
-			}
+	if render.Color, err = UseColor(color, os.Stdout); err != nil {
+		return false, err
+	}
 
-			return nil
-		},
+	var env1, env2 environment.Environment
+
+	if len(args) == 1 {
+		env1, err = LoadProfile(options)
+	} else {
+		env1, err = LoadSource(options, args[0])
+		env1.Name = args[0]
 	}
 
-	cmd.Flags().SortFlags = false
+	if err != nil {
+		return false, err
+	}
 
-	return cmd
+	env2, err = LoadSource(options, args[len(args)-1])
+	if err != nil {
+		return false, err
+	}
+
+	env2.Name = args[len(args)-1]
+
+	diff, err := environment.Diffs(env1.Env, env2.Env).Filter(include, exclude)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case quiet:
+	case diff.Equal() && render.Format != environment.JSONDiff && render.Format != environment.SideBySide:
+		//nolint:forbidigo	// Command prints out to the console.
+		fmt.Println("No differences found.")
+	default:
+		if err := diff.Render(os.Stdout, env1.Name, env2.Name, render); err != nil {
+			return false, err
+		}
+	}
+
+	return diff.Equal(), nil
 }
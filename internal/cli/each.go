@@ -0,0 +1,392 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/audit"
+	"github.com/idelchi/envprof/internal/environment"
+	"github.com/idelchi/envprof/internal/profiles"
+	execx "github.com/idelchi/envprof/pkg/exec"
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// Each returns the cobra command for running a command across several profiles.
+//
+//nolint:funlen	// Long help text and flags.
+func Each(options *Options) *cobra.Command {
+	current := env.FromEnv()
+
+	var (
+		patterns []string
+		all      bool
+		parallel = 1
+		failFast bool
+		group    bool
+		isolate  bool
+		path     bool
+		envs     []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "each [flags] -- <command> [args...]",
+		Short: "Run a command across several profiles",
+		Long: heredoc.Doc(`
+			Run a command once per profile, each with the profile's environment.
+
+			Profiles are selected with --all or --profiles, which accepts names and glob patterns.
+			Commands run sequentially by default, or up to N at a time with --parallel N.
+
+			Each line of output is prefixed with the profile name, or grouped per profile with --group.
+			A summary of the exit status per profile is printed at the end,
+			and envprof exits with 1 if any profile failed.
+
+			With --fail-fast, the first failure cancels the running commands and skips the remaining profiles.
+
+			If a profile has a 'ttl', its command is terminated once the ttl expires, counting from its start.
+			Commands are terminated with SIGTERM on Unix, and killed if they have not exited 5 seconds later.
+
+			Hooks of the profiles are not run.
+		`),
+		Example: heredoc.Doc(`
+			# Run a smoke test against three profiles
+			envprof each --profiles dev,staging,prod -- ./smoke-test.sh
+
+			# Run migrations for all profiles, four at a time, stopping at the first failure
+			envprof each --all --parallel 4 --fail-fast -- make migrate
+
+			# Select profiles with a glob, grouping the output per profile
+			envprof each --profiles 'eu-*' --group -- terraform plan
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			switch {
+			case all && len(patterns) > 0:
+				return errors.New("'--all' and '--profiles' are incompatible and may not be used together")
+			case !all && len(patterns) == 0:
+				return errors.New("one of '--profiles' or '--all' is required")
+			case parallel < 1:
+				return fmt.Errorf("'--parallel' must be at least 1, got %d", parallel)
+			}
+
+			ep, err := LoadEnvProf(options)
+			if err != nil {
+				return err
+			}
+
+			prof := ep.Profiles()
+
+			if all {
+				patterns = []string{"*"}
+			}
+
+			names, err := selectProfiles(prof, patterns)
+			if err != nil {
+				return err
+			}
+
+			jobs := make([]eachJob, 0, len(names))
+
+			for _, name := range names {
+				steps, err := prof.Plan(name, options.Overlay...)
+				if err != nil {
+					return err
+				}
+
+				if err := Confirm(options, prof, steps); err != nil {
+					return err
+				}
+
+				ttl, err := prof.TTL(steps)
+				if err != nil {
+					return err
+				}
+
+				entry := audit.Entry{Profile: name, Overlays: options.Overlay, Command: options.Command, Args: args}
+
+				if err := Record(ep, entry); err != nil {
+					return err
+				}
+
+				env, err := prof.Environment(name, steps)
+				if err != nil {
+					return err
+				}
+
+				env.Env = Merge(env.Env, current, isolate, path, envs)
+
+				jobs = append(jobs, eachJob{env: env, ttl: ttl})
+			}
+
+			runner := eachRunner{parallel: parallel, failFast: failFast, group: group}
+
+			results := runner.run(jobs, args[0], args[1:])
+
+			summarize(results)
+
+			if failed := slices.DeleteFunc(slices.Clone(results), eachResult.ok); len(failed) > 0 {
+				return fmt.Errorf("%d of %d profiles did not succeed", len(failed), len(results))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().
+		StringSliceVarP(&patterns, "profiles", "P", nil, "Profiles to run the command for, as names or glob patterns")
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "Run the command for all profiles")
+	cmd.Flags().IntVarP(&parallel, "parallel", "j", parallel, "Number of commands to run at a time")
+	cmd.Flags().BoolVar(&failFast, "fail-fast", false, "Cancel and skip the remaining profiles after the first failure")
+	cmd.Flags().BoolVarP(&group, "group", "g", false, "Group the output per profile instead of prefixing each line")
+	cmd.Flags().BoolVarP(&isolate, "isolate", "i", false, "Isolate from parent environment")
+	cmd.Flags().BoolVarP(&path, "path", "p", false, "Include the current PATH in the environment")
+	cmd.Flags().
+		StringSliceVarP(&envs, "env", "e", nil, "Passthrough environment variables (combined with --isolate)")
+
+	return cmd
+}
+
+// selectProfiles returns the profiles matching the names or glob patterns, in order of the patterns.
+func selectProfiles(prof profiles.Profiles, patterns []string) ([]string, error) {
+	var names []string
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		matched := false
+
+		for _, name := range prof.Names() {
+			if ok, _ := path.Match(pattern, name); ok {
+				matched = true
+
+				if !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("no profiles match %q", pattern)
+		}
+	}
+
+	return names, nil
+}
+
+// eachResult is the outcome of running the command for a profile.
+type eachResult struct {
+	// name is the profile name.
+	name string
+	// status describes the outcome, e.g. "ok", "exit status 1" or "skipped".
+	status string
+}
+
+// ok reports whether the command succeeded.
+func (r eachResult) ok() bool {
+	return r.status == "ok"
+}
+
+// eachJob is a profile to run the command for.
+type eachJob struct {
+	// env is the environment of the profile.
+	env environment.Environment
+	// ttl is the time-to-live of the profile, or 0 if unlimited.
+	ttl time.Duration
+}
+
+// eachRunner runs a command across environments.
+type eachRunner struct {
+	parallel int
+	failFast bool
+	group    bool
+
+	// mu serializes writes to the console.
+	mu sync.Mutex
+}
+
+// run runs the command for each job, returning the results in the order of the jobs.
+func (r *eachRunner) run(jobs []eachJob, command string, args []string) []eachResult {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make([]eachResult, len(jobs))
+	slots := make(chan struct{}, r.parallel)
+
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		slots <- struct{}{}
+
+		if ctx.Err() != nil {
+			results[i] = eachResult{name: job.env.Name, status: "skipped"}
+
+			<-slots
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i] = r.one(ctx, job, command, args)
+
+			if !results[i].ok() && r.failFast {
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// one runs the command for a single job, terminating it once the ttl of the profile expires.
+func (r *eachRunner) one(ctx context.Context, job eachJob, command string, args []string) eachResult {
+	env := job.env
+
+	if job.ttl > 0 {
+		expires := time.Now().Add(job.ttl)
+
+		if err := env.Env.AddPair(Expires, expires.UTC().Format(time.RFC3339)); err != nil {
+			return eachResult{name: env.Name, status: err.Error()}
+		}
+
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithDeadline(ctx, expires)
+		defer cancel()
+	}
+
+	var (
+		buffer bytes.Buffer
+		stdout io.Writer = &prefixWriter{mu: &r.mu, out: os.Stdout, prefix: "[" + env.Name + "] "}
+		stderr io.Writer = &prefixWriter{mu: &r.mu, out: os.Stderr, prefix: "[" + env.Name + "] "}
+	)
+
+	if r.group {
+		stdout, stderr = &buffer, &buffer
+	}
+
+	cmd := execx.Command(ctx, command, args...)
+
+	cmd.Env = env.Env.AsSlice()
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	err := cmd.Run()
+
+	for _, writer := range []io.Writer{stdout, stderr} {
+		if prefixed, ok := writer.(*prefixWriter); ok {
+			prefixed.Flush()
+		}
+	}
+
+	if r.group {
+		r.mu.Lock()
+		//nolint:forbidigo	// Command prints out to the console.
+		fmt.Printf("==> %s <==\n%s", env.Name, buffer.String())
+		r.mu.Unlock()
+	}
+
+	var ee *exec.ExitError
+
+	switch {
+	case err == nil:
+		return eachResult{name: env.Name, status: "ok"}
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return eachResult{name: env.Name, status: "expired after " + job.ttl.String()}
+	case ctx.Err() != nil:
+		return eachResult{name: env.Name, status: "canceled"}
+	case errors.As(err, &ee):
+		return eachResult{name: env.Name, status: ee.ProcessState.String()}
+	default:
+		return eachResult{name: env.Name, status: err.Error()}
+	}
+}
+
+// summarize prints the status of each profile as a table.
+func summarize(results []eachResult) {
+	var builder strings.Builder
+
+	//nolint:mnd 	// minwidth, tabwidth, padding, padchar, flags
+	writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(writer, "PROFILE\tSTATUS")
+
+	for _, result := range results {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\n", result.name, result.status)
+	}
+
+	_ = writer.Flush()
+
+	//nolint:forbidigo	// Command prints out to the console.
+	fmt.Print("\n" + builder.String())
+}
+
+// prefixWriter writes complete lines to out, each prefixed, holding back incomplete lines until flushed.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buffer []byte
+}
+
+// Write buffers p and writes out all complete lines.
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buffer = append(w.buffer, p...)
+
+	index := bytes.LastIndexByte(w.buffer, '\n')
+	if index < 0 {
+		return len(p), nil
+	}
+
+	w.write(w.buffer[:index+1])
+
+	w.buffer = w.buffer[index+1:]
+
+	return len(p), nil
+}
+
+// Flush writes out a remaining incomplete line.
+func (w *prefixWriter) Flush() {
+	if len(w.buffer) > 0 {
+		w.write(append(w.buffer, '\n'))
+
+		w.buffer = nil
+	}
+}
+
+// write writes the lines with the prefix.
+func (w *prefixWriter) write(lines []byte) {
+	var builder strings.Builder
+
+	for line := range strings.Lines(string(lines)) {
+		builder.WriteString(w.prefix + line)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, _ = io.WriteString(w.out, builder.String())
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/idelchi/envprof/internal/edit"
+	"github.com/idelchi/envprof/pkg/atomicfile"
+)
+
+// Edit applies the changes to the config file in place, preserving comments and formatting.
+// The edited file must still load, otherwise it is left untouched.
+func Edit(options *Options, change func(doc edit.Document) error) error {
+	ep, err := EnvProf(options)
+	if err != nil {
+		return err
+	}
+
+	path := ep.File().Path()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if err := ep.Type(); err != nil {
+		if err := ep.TryParse(data); err != nil {
+			return fmt.Errorf("editing %q: %w", path, err)
+		}
+	}
+
+	doc, err := edit.Parse(data, edit.Format(ep.Format()))
+	if err != nil {
+		return fmt.Errorf("editing %q: %w", path, err)
+	}
+
+	if err := change(doc); err != nil {
+		return err
+	}
+
+	edited := doc.Bytes()
+
+	if err := ep.LoadBytes(edited); err != nil {
+		return fmt.Errorf("edited file would be invalid, leaving %q untouched: %w", path, err)
+	}
+
+	return atomicfile.Write(path, edited, info.Mode().Perm())
+}
+
+// EditedProfile returns the profile to edit: the given one, the one selected with --profile of envprof
+// or ENVPROF_PROFILE, or the profile marked with 'default: true'.
+// The 'default_when' rules are ignored, so that the edited profile does not depend on the current context.
+func EditedProfile(options *Options, profile string) (string, error) {
+	if profile == "" {
+		profile = options.Profile
+	}
+
+	ep, err := LoadEnvProf(options)
+	if err != nil {
+		return "", err
+	}
+
+	selection, err := ep.SelectStatic(profile)
+	if err != nil && ep.Profiles().HasRules(false) {
+		return "", fmt.Errorf("%w, 'default_when' rules are not used when editing", err)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return selection.Name, nil
+}
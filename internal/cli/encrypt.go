@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/edit"
+	"github.com/idelchi/envprof/internal/profile"
+	"github.com/idelchi/envprof/internal/secret"
+)
+
+// Encrypt returns the cobra command for encrypting values of a profile in the config file.
+func Encrypt(options *Options) *cobra.Command {
+	var (
+		profile  string
+		generate bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "encrypt KEY [KEY...]",
+		Short: "Encrypt values of a profile in the config file",
+		Long: heredoc.Docf(`
+			Encrypt the values of the given keys of a profile in place, leaving the rest of the file untouched.
+
+			Values are encrypted with AES-256-GCM using a local key, taken from %s (base64-encoded)
+			or the key file at %s (default ~/.config/envprof/key).
+			If neither exists, pass --init to generate a new key file. Back it up and share it only with those
+			who need to decrypt the values, as they cannot be recovered without it.
+
+			Encrypted values start with %q and are decrypted whenever the profile is resolved.
+			They are bound to their profile and key, and 'profile copy' and 'profile rename' re-encrypt them.
+			Plain values starting with %[3]q cannot be stored as is, encrypt them instead.
+		`, secret.KeyVariable, secret.KeyFileVariable, secret.Prefix),
+		Example: heredoc.Doc(`
+			# Generate a key file on first use
+			envprof encrypt DB_PASSWORD --profile prod --init
+
+			# Encrypt a value of the 'prod' profile
+			envprof encrypt DB_PASSWORD --profile prod
+
+			# Use a key from the environment, e.g. in CI
+			ENVPROF_KEY="$(cat key)" envprof encrypt API_TOKEN
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, keys []string) error {
+			key, err := secret.Load()
+
+			switch {
+			case errors.Is(err, secret.ErrNoKey) && generate:
+				key, err = generateKey()
+			case errors.Is(err, secret.ErrNoKey):
+				err = fmt.Errorf("%w, pass --init to generate a new key file", err)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			return crypt(options, profile, keys, func(profile, name, value string) (string, error) {
+				if secret.IsEncrypted(value) {
+					return "", fmt.Errorf("env %q is already encrypted", name)
+				}
+
+				return key.Encrypt(profile, name, value)
+			})
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().
+		StringVarP(&profile, "profile", "p", "", "Profile to edit (defaults to the selected profile, ignoring default_when)")
+	cmd.Flags().BoolVar(&generate, "init", false, "Generate a new key file if no key is configured")
+
+	return cmd
+}
+
+// Decrypt returns the cobra command for decrypting values of a profile in the config file.
+func Decrypt(options *Options) *cobra.Command {
+	var profile string
+
+	cmd := &cobra.Command{
+		Use:   "decrypt KEY [KEY...]",
+		Short: "Decrypt values of a profile in the config file",
+		Long: heredoc.Doc(`
+			Decrypt the values of the given keys of a profile in place, leaving the rest of the file untouched.
+
+			Uses the same key as 'encrypt'.
+		`),
+		Example: heredoc.Doc(`
+			# Decrypt a value of the 'prod' profile, e.g. to rotate the key
+			envprof decrypt DB_PASSWORD --profile prod
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, keys []string) error {
+			key, err := secret.Load()
+			if err != nil {
+				return err
+			}
+
+			return crypt(options, profile, keys, func(profile, name, value string) (string, error) {
+				if !secret.IsEncrypted(value) {
+					return "", fmt.Errorf("env %q is not encrypted", name)
+				}
+
+				return key.Decrypt(profile, name, value)
+			})
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().
+		StringVarP(&profile, "profile", "p", "", "Profile to edit (defaults to the selected profile, ignoring default_when)")
+
+	return cmd
+}
+
+// crypt replaces the values of the keys of the profile in the config file with their transformed values.
+func crypt(
+	options *Options,
+	profile string,
+	keys []string,
+	transform func(profile, key, value string) (string, error),
+) error {
+	profile, err := EditedProfile(options, profile)
+	if err != nil {
+		return err
+	}
+
+	return Edit(options, func(doc edit.Document) error {
+		for _, key := range keys {
+			value, err := doc.Value(profile, key)
+			if err != nil {
+				return err
+			}
+
+			if value, err = transform(profile, key, value); err != nil {
+				return fmt.Errorf("profile %q: %w", profile, err)
+			}
+
+			if err := doc.Replace(profile, key, value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// generateKey generates a new key file, as no key is configured yet.
+func generateKey() (secret.Key, error) {
+	path, err := secret.KeyFile()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := secret.Generate(path)
+	if err != nil {
+		return nil, fmt.Errorf("generating key file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Generated key file %q\n", path)
+	fmt.Fprintln(os.Stderr, "Back it up: encrypted values cannot be decrypted without it")
+
+	return key, nil
+}
+
+// rebind re-encrypts the encrypted values of a profile copied or renamed to a new name,
+// as they are bound to the profile they were encrypted in. The key is only loaded if needed.
+func rebind(doc edit.Document, prof profile.Profile, from, to string) error {
+	env, err := prof.Env.Stringified()
+	if err != nil {
+		return err
+	}
+
+	var key secret.Key
+
+	for _, name := range env.Keys() {
+		if !secret.IsEncrypted(env[name]) {
+			continue
+		}
+
+		if key == nil {
+			if key, err = secret.Load(); err != nil {
+				return fmt.Errorf("re-encrypting values of profile %q: %w", from, err)
+			}
+		}
+
+		value, err := key.Decrypt(from, name, env[name])
+		if err != nil {
+			return fmt.Errorf("profile %q: env %q: %w", from, name, err)
+		}
+
+		if value, err = key.Encrypt(to, name, value); err != nil {
+			return err
+		}
+
+		if err := doc.Replace(to, name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
@@ -22,6 +25,8 @@ func Exec(options *Options) *cobra.Command {
 		isolate     bool
 		path        bool
 		interactive bool
+		noReplace   bool
+		script      string
 		envs        []string
 	)
 
@@ -34,7 +39,26 @@ func Exec(options *Options) *cobra.Command {
 			On Unix, replaces the current process.
 			On Windows, runs and exits with the same code.
 
+			With --no-replace, runs the command as a child process instead,
+			forwarding SIGTERM and SIGHUP and exiting with its exit code.
+			Terminal signals (SIGINT, SIGQUIT and SIGWINCH) are forwarded as well, unless envprof runs
+			in the foreground of a terminal, which sends them to the command itself.
+			There, send them to the process group (kill -INT -<pid>) rather than to envprof alone.
+
+			The 'pre' hooks of the profile run before the command, aborting if any fails.
+			The 'post' hooks run after the command regardless of its outcome,
+			with its exit code in ENVPROF_EXIT_CODE, and imply --no-replace.
+			Hooks are shell commands run with the profile's environment.
+
+			If the profile has a 'ttl', the command runs as a child process and is terminated once the ttl expires.
+
 			Optionally allows to pass <command> and [args...] via stdin when <command> is "-".
+			A single simple command is split into words as by a POSIX shell, with variables
+			expanded from the profile's environment and braces and globs expanded as by bash.
+			Anything else, such as multi-line scripts,
+			pipelines or redirections, is run as a script with the detected shell.
+
+			With --script, runs the script file with the detected shell, passing [args...] to it.
     	`),
 		Example: heredoc.Doc(`
 			# Run a command with 'dev'
@@ -51,62 +75,97 @@ func Exec(options *Options) *cobra.Command {
 
 			# Run command and arguments passed with stdin
 			echo "node --version" | envprof --profile dev exec --interactive -
+
+			# Run a multi-line script passed with stdin
+			envprof --profile dev exec - <<'EOF'
+			  cd infra
+			  terraform plan | tee plan.txt
+			EOF
+
+			# Run a script file with arguments
+			envprof --profile dev exec --script ./migrate.sh -- --dry-run
+
+			# Run as a child process, e.g. to run the profile's 'post' hooks
+			envprof --profile dev exec --no-replace -- terraform apply
       	`),
 		Aliases: []string{"ex"},
-		Args:    cobra.MinimumNArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if script != "" {
+				return nil
+			}
+
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: func(_ *cobra.Command, args []string) error {
-			profile, err := LoadProfile(options)
+			activation, err := Activate(options)
 			if err != nil {
 				return err
 			}
 
+			hooks, err := activation.Profiles.Get(activation.Name)
+			if err != nil {
+				return err
+			}
+
+			profile := activation.Environment
+
 			profile.Env = Merge(profile.Env, environment, isolate, path, envs)
 
-			cmd := args[0]
+			cmd, args, shell, err := resolveCommand(args, script, interactive, profile.Env)
+			if err != nil {
+				return err
+			}
 
-			if cmd == "-" {
-				if ok, err := IsStdinPiped(); err != nil {
-					return err
-				} else if !ok {
-					return errors.New("no input from stdin")
-				}
+			//nolint:forbidigo	// Command prints out to the console.
+			if options.Verbose && shell.Interactive() {
+				fmt.Printf("Using login shell: %q\n", shell)
+			}
 
-				args, err = Read()
-				if err != nil {
-					return err
-				}
+			//nolint:forbidigo	// Command prints out to the console.
+			if options.Verbose {
+				fmt.Printf("Executing command %q with args %q\n", cmd, args)
+			}
 
-				cmd = args[0]
+			if err := activation.Record(append([]string{cmd}, args...)); err != nil {
+				return err
+			}
 
-				if cmd == "" {
-					return errors.New("no input from stdin")
-				}
+			if err := runHooks("pre", hooks.Pre, profile.Env); err != nil {
+				return err
 			}
 
-			if len(args) > 1 {
-				args = args[1:]
-			} else {
-				args = nil
+			if !noReplace && len(hooks.Post) == 0 && activation.TTL == 0 {
+				return execx.Replace(cmd, args, profile.Env.AsSlice(), shell)
 			}
 
-			var shell terminal.Shell
+			ctx := context.Background()
 
-			if interactive {
-				shell = terminal.Current()
+			if activation.TTL > 0 {
+				var cancel context.CancelFunc
 
-				//nolint:forbidigo	// Command prints out to the console.
-				if options.Verbose {
-					fmt.Printf("Using login shell: %q\n", shell)
-				}
+				ctx, cancel = context.WithDeadline(ctx, activation.Expires)
+				defer cancel()
 			}
 
-			//nolint:forbidigo	// Command prints out to the console.
-			if options.Verbose {
-				fmt.Printf("Executing command %q with args %q\n", cmd, args)
+			code, err := execx.Run(ctx, cmd, args, profile.Env.AsSlice(), shell)
+			if err != nil {
+				return err
 			}
 
-			if err := execx.Replace(cmd, args, profile.Env.AsSlice(), shell); err != nil {
-				return err
+			var expired error
+
+			if ctx.Err() != nil {
+				expired = fmt.Errorf("profile %q expired after %s, command terminated", activation.Name, activation.TTL)
+			}
+
+			post := profile.Env.MergedWith(env.Env{"ENVPROF_EXIT_CODE": strconv.Itoa(code)})
+
+			if err := runHooks("post", hooks.Post, post); err != nil {
+				return ExitError{Code: max(code, 1), Err: errors.Join(expired, err)}
+			}
+
+			if code != 0 || expired != nil {
+				return ExitError{Code: max(code, 1), Err: expired}
 			}
 
 			return nil
@@ -118,8 +177,83 @@ func Exec(options *Options) *cobra.Command {
 	cmd.Flags().BoolVarP(&isolate, "isolate", "i", false, "Isolate from parent environment")
 	cmd.Flags().BoolVarP(&path, "path", "p", false, "Include the current PATH in the environment")
 	cmd.Flags().BoolVarP(&interactive, "interactive", "I", false, "Run in interactive mode")
+	cmd.Flags().StringVarP(&script, "script", "s", "", "Script file to run with the detected shell, passing [args...]")
+	cmd.Flags().BoolVarP(&noReplace, "no-replace", "n", false, "Run as a child process instead of replacing envprof")
 	cmd.Flags().
 		StringSliceVarP(&envs, "env", "e", nil, "Passthrough environment variables (combined with --isolate)")
 
 	return cmd
 }
+
+// resolveCommand determines the command and its arguments from the positional arguments,
+// stdin (when the command is "-") or a script file, along with the shell to run it in.
+// Scripts are run by the detected shell directly, so no shell is returned for them.
+func resolveCommand(
+	args []string,
+	script string,
+	interactive bool,
+	env env.Env,
+) (string, []string, terminal.Shell, error) {
+	var shell terminal.Shell
+
+	if interactive {
+		shell = terminal.Current()
+	}
+
+	switch {
+	case script != "":
+		current := terminal.Current()
+
+		return string(current), current.File(script, args), "", nil
+	case args[0] == "-":
+		if ok, err := IsStdinPiped(); err != nil {
+			return "", nil, "", err
+		} else if !ok {
+			return "", nil, "", errors.New("no input from stdin")
+		}
+
+		input, err := Read()
+		if err != nil {
+			return "", nil, "", err
+		}
+
+		if strings.TrimSpace(input) == "" {
+			return "", nil, "", errors.New("no input from stdin")
+		}
+
+		fields, simple, err := execx.Split(input, env.AsSlice())
+		if err != nil {
+			return "", nil, "", fmt.Errorf("parsing stdin: %w", err)
+		}
+
+		if !simple {
+			current := terminal.Current()
+
+			return string(current), current.Inline(input, interactive), "", nil
+		}
+
+		if len(fields) == 0 {
+			return "", nil, "", errors.New("no command from stdin")
+		}
+
+		return fields[0], fields[1:], shell, nil
+	default:
+		return args[0], args[1:], shell, nil
+	}
+}
+
+// runHooks runs the hooks of the given kind in order with the environment, stopping at the first failure.
+func runHooks(kind string, hooks []string, env env.Env) error {
+	for _, hook := range hooks {
+		code, err := execx.Script(hook, env.AsSlice())
+		if err != nil {
+			return fmt.Errorf("%s hook %q: %w", kind, hook, err)
+		}
+
+		if code != 0 {
+			return fmt.Errorf("%s hook %q: exit status %d", kind, hook, code)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"strconv"
+)
+
+// ExitError is an error carrying the exit code the process should terminate with.
+// A nil Err terminates silently.
+type ExitError struct {
+	// Code is the exit code.
+	Code int
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error returns the message of the underlying error.
+func (e ExitError) Error() string {
+	if e.Err == nil {
+		return "exit status " + strconv.Itoa(e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e ExitError) Unwrap() error {
+	return e.Err
+}
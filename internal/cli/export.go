@@ -24,6 +24,7 @@ func Export(options *Options) *cobra.Command {
 			 ...
 
 			Default prefix is "export ". Override with --prefix (e.g., "$env:" on PowerShell).
+			Values are quoted for PowerShell with a "$env:" prefix, and for POSIX shells otherwise.
 		`),
 		Example: heredoc.Doc(`
 			# Emit 'export KEY=VAL' lines for 'dev'
@@ -35,14 +36,21 @@ func Export(options *Options) *cobra.Command {
 		Aliases: []string{"x"},
 		Args:    cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			env, err := LoadProfile(options)
+			activation, err := Activate(options)
 			if err != nil {
 				return err
 			}
 
+			if err := activation.Record(nil); err != nil {
+				return err
+			}
+
+			env := activation.Environment
+
 			formatter := environment.Formatter{
 				WithKey: true,
 				Prefix:  prefix,
+				Quote:   environment.ExportQuoter(prefix),
 			}
 
 			envs := formatter.All(env)
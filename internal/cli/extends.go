@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/edit"
+)
+
+// Extends returns the cobra command for adding and removing extends of a profile in the config file.
+func Extends(options *Options) *cobra.Command {
+	var profile string
+
+	cmd := &cobra.Command{
+		Use:   "extends",
+		Short: "Add and remove extends of a profile in the config file",
+		Long: heredoc.Doc(`
+			Add and remove extends entries of a profile in place, leaving the rest of the file untouched.
+
+			Entries are profile names or prefixed references, such as 'profile:base' or 'dotenv:.env'.
+			When removing, 'base' and 'profile:base' refer to the same entry.
+		`),
+		Example: heredoc.Doc(`
+			# Make 'dev' extend 'base' and a dotenv file
+			envprof extends add base dotenv:.env --profile dev
+
+			# Stop extending 'base'
+			envprof extends remove base --profile dev
+		`),
+		Args: cobra.NoArgs,
+		RunE: UnknownSubcommandAction,
+	}
+
+	cmd.PersistentFlags().
+		StringVarP(&profile, "profile", "p", "", "Profile to edit (defaults to the selected profile, ignoring default_when)")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "add ENTRY [ENTRY...]",
+			Short: "Append entries to the extends of a profile, skipping those already present",
+			Args:  cobra.MinimumNArgs(1),
+			RunE: func(_ *cobra.Command, entries []string) error {
+				profile, err := EditedProfile(options, profile)
+				if err != nil {
+					return err
+				}
+
+				return Edit(options, func(doc edit.Document) error { return edit.AddExtends(doc, profile, entries...) })
+			},
+		},
+		&cobra.Command{
+			Use:   "remove ENTRY [ENTRY...]",
+			Short: "Remove entries from the extends of a profile",
+			Args:  cobra.MinimumNArgs(1),
+			RunE: func(_ *cobra.Command, entries []string) error {
+				profile, err := EditedProfile(options, profile)
+				if err != nil {
+					return err
+				}
+
+				return Edit(options, func(doc edit.Document) error {
+					return edit.RemoveExtends(doc, profile, entries...)
+				})
+			},
+		},
+	)
+
+	return cmd
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/edit"
+	"github.com/idelchi/envprof/pkg/atomicfile"
+)
+
+// Fmt returns the cobra command for formatting the config file.
+//
+//nolint:funlen	// Long help text and flags.
+func Fmt(options *Options) *cobra.Command {
+	var (
+		check bool
+		style = edit.Style{Order: edit.ByName, Env: edit.KeepEnv}
+	)
+
+	orders := []edit.Order{edit.ByName, edit.ByDependency}
+	envs := []edit.EnvStyle{edit.KeepEnv, edit.MapEnv, edit.SequenceEnv}
+
+	cmd := &cobra.Command{
+		Use:   "fmt",
+		Short: "Format the config file",
+		Long: heredoc.Doc(`
+			Format the config file in place, preserving comments.
+
+			The settings come first, followed by the profiles sorted by name, or with --order dependency,
+			with extended profiles before the profiles extending them.
+			Env is sorted by key, and with --env written as mappings or sequences (YAML only).
+			Extends of profiles get an explicit 'profile:' prefix, and values are quoted consistently.
+
+			The template source is formatted, which only works if the templates do not span YAML or TOML syntax.
+
+			The formatted file must load as the same profiles and settings, or nothing is written.
+
+			With --check, nothing is written. Instead, the command fails if the file is not formatted.
+		`),
+		Example: heredoc.Doc(`
+			# Format the config file
+			envprof fmt
+
+			# Write all env as mappings, with base profiles first
+			envprof fmt --env map --order dependency
+
+			# Verify in CI that the config file is formatted
+			envprof fmt --check
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !slices.Contains(orders, style.Order) {
+				return fmt.Errorf("unsupported order %q, must be one of %v", style.Order, orders)
+			}
+
+			if !slices.Contains(envs, style.Env) {
+				return fmt.Errorf("unsupported env style %q, must be one of %v", style.Env, envs)
+			}
+
+			ep, err := LoadEnvProf(options)
+			if err != nil {
+				return err
+			}
+
+			path := ep.File().Path()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			formatted, err := edit.Normalize(data, edit.Format(ep.Format()), style)
+			if err != nil {
+				return fmt.Errorf("formatting %q: %w", path, err)
+			}
+
+			if bytes.Equal(data, formatted) {
+				return nil
+			}
+
+			if check {
+				return fmt.Errorf("%q is not formatted, run 'envprof fmt'", path)
+			}
+
+			profiles, settings := ep.Profiles(), ep.Settings()
+
+			if err := ep.LoadBytes(formatted); err != nil {
+				return fmt.Errorf("formatted file would be invalid, leaving %q untouched: %w", path, err)
+			}
+
+			if err := profiles.Mismatch(ep.Profiles()); err != nil {
+				return fmt.Errorf("formatting would change the file's content, leaving %q untouched: %w", path, err)
+			}
+
+			if settings != ep.Settings() {
+				return fmt.Errorf("formatting would change the file's settings, leaving %q untouched", path)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+
+			return atomicfile.Write(path, formatted, info.Mode().Perm())
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().BoolVarP(&check, "check", "c", false, "Verify that the file is formatted, without writing")
+	cmd.Flags().StringVar((*string)(&style.Order), "order", string(style.Order),
+		fmt.Sprintf("Order of the profiles, one of %v", orders))
+	cmd.Flags().StringVarP((*string)(&style.Env), "env", "e", string(style.Env),
+		fmt.Sprintf("Style of env, one of %v", envs))
+
+	return cmd
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/profiles"
+)
+
+// Graph returns the cobra command for rendering the extends graph of all profiles.
+func Graph(options *Options) *cobra.Command {
+	format := "tree"
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Render the extends graph of all profiles",
+		Long: heredoc.Doc(`
+			Render the inheritance graph of all profiles and the dotenv files they extend.
+
+			The default profile, missing targets, unsupported extends, cycles and
+			profiles not used by any profile selectable by default (default or default_when) are marked.
+
+			Output formats are tree (ASCII), dot (Graphviz) and mermaid.
+		`),
+		Example: heredoc.Doc(`
+			# Show the graph as a tree
+			envprof graph
+
+			# Render an image with Graphviz
+			envprof graph --format dot | dot -Tsvg > profiles.svg
+
+			# Embed the graph in Markdown
+			envprof graph --format mermaid
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			// Invalid files are rendered as far as possible, to help find the problem.
+			envprof, err := LoadEnvProf(options)
+			if err != nil && !errors.Is(err, profiles.ErrValidation) {
+				return err
+			}
+
+			graph := envprof.Profiles().Graph()
+
+			var output string
+
+			switch format {
+			case "tree":
+				output = graph.Tree()
+			case "dot":
+				output = graph.DOT()
+			case "mermaid":
+				output = graph.Mermaid()
+			default:
+				return fmt.Errorf("unsupported format %q, must be one of tree, dot or mermaid", format)
+			}
+
+			//nolint:forbidigo	// Command prints out to the console.
+			fmt.Print(output)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVarP(&format, "format", "F", format, "Output format (tree, dot or mermaid)")
+
+	return cmd
+}
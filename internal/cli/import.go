@@ -0,0 +1,274 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/edit"
+	"github.com/idelchi/envprof/internal/environment"
+	"github.com/idelchi/envprof/internal/envprof"
+	"github.com/idelchi/envprof/pkg/atomicfile"
+	"github.com/idelchi/envprof/pkg/dotenv"
+	"github.com/idelchi/godyl/pkg/env"
+	"github.com/idelchi/godyl/pkg/path/files"
+)
+
+// Import returns the cobra command for importing dotenv files as new profiles into the config file.
+//
+//nolint:funlen	// Long help text and flags.
+func Import(options *Options) *cobra.Command {
+	var (
+		as      string
+		extends []string
+		dedupe  bool
+		base    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import FILE [FILE...]",
+		Short: "Import dotenv files as new profiles into the config file",
+		Long: heredoc.Doc(`
+			Import dotenv files as new profiles, leaving the rest of the config file untouched.
+			If there is no config file yet, the first candidate is created, with relative dotenv paths
+			resolved against its directory ('relative_to: config').
+
+			Each file becomes a profile named after it, such as 'staging' for '.env.staging' or 'staging.env',
+			unless named with --as. Variables are added as strings, sorted by key.
+			References to variables not assigned in the file, such as ${HOME}, cannot be kept, as profiles do not
+			expand them: they are imported as their default value or empty, with a warning for each.
+			Use a template such as {{ .HOME }} in the config file, or extend the file with 'dotenv:', to read them
+			when loading instead.
+
+			With --dedupe, variables with the same value in all files are extracted into a shared
+			base profile, which the imported profiles extend, keeping only their overrides.
+			The --extends entries are then added to the base profile instead.
+		`),
+		Example: heredoc.Doc(`
+			# Import a file as the 'staging' profile, extending 'base'
+			envprof import .env.staging --as staging --extends base
+
+			# Import several files, extracting the common variables into a 'common' profile
+			envprof import .env.dev .env.staging .env.prod --dedupe --base common
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, files []string) error {
+			if as != "" && len(files) > 1 {
+				return errors.New("'--as' can only be used with a single file")
+			}
+
+			if dedupe && len(files) < 2 { //nolint:mnd	// Deduplication needs several files.
+				return errors.New("'--dedupe' needs at least two files")
+			}
+
+			if dedupe && base == "" {
+				return errors.New("'--base' cannot be empty with '--dedupe'")
+			}
+
+			names := make([]string, 0, len(files))
+			envs := make([]env.Env, 0, len(files))
+
+			for _, file := range files {
+				name := as
+				if name == "" {
+					var err error
+					if name, err = importName(file); err != nil {
+						return err
+					}
+				}
+
+				variables, err := importFile(file)
+				if err != nil {
+					return err
+				}
+
+				names = append(names, name)
+				envs = append(envs, variables)
+			}
+
+			if !dedupe {
+				base = ""
+			}
+
+			imports := importProfiles(names, envs, extends, base)
+
+			if dedupe && len(imports) == len(names) {
+				fmt.Fprintln(os.Stderr, "No variables common to all files, skipping the base profile")
+			}
+
+			if err := createConfig(options); err != nil {
+				return err
+			}
+
+			for i, profile := range imports {
+				if err := newProfile(options, profile.name); err != nil {
+					return err
+				}
+
+				if slices.ContainsFunc(imports[:i], func(other imported) bool { return other.name == profile.name }) {
+					return fmt.Errorf("profile %q would be imported twice", profile.name)
+				}
+			}
+
+			return Edit(options, func(doc edit.Document) error {
+				for _, profile := range imports {
+					if err := importProfile(doc, profile); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&as, "as", "", "Name of the imported profile, for a single file")
+	cmd.Flags().
+		StringSliceVarP(&extends, "extends", "e", nil, "Extends of the imported profiles, or of the base with --dedupe")
+	cmd.Flags().BoolVarP(&dedupe, "dedupe", "d", false, "Extract variables common to all files into a base profile")
+	cmd.Flags().StringVarP(&base, "base", "b", "base", "Name of the base profile with --dedupe")
+
+	return cmd
+}
+
+// imported is a profile to import.
+type imported struct {
+	name    string
+	extends []string
+	env     env.Env
+}
+
+// createConfig creates the preferred config file if none exists yet,
+// resolving relative dotenv paths against its directory (see envprof.Config).
+func createConfig(options *Options) error {
+	candidates := files.New("", options.EnvProf...)
+	candidates.Expanded()
+
+	if _, ok := candidates.Exists(); ok || len(candidates) == 0 {
+		return nil
+	}
+
+	path := candidates[0].Path()
+
+	content := fmt.Sprintf("%s:\n  relative_to: %s\n", envprof.Reserved, envprof.Config)
+	if candidates[0].Extension() == "toml" {
+		content = fmt.Sprintf("[%s]\nrelative_to = %q\n", envprof.Reserved, envprof.Config)
+	}
+
+	if err := atomicfile.Write(path, []byte(content), environment.DefaultMode); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Created %q\n", path)
+
+	return nil
+}
+
+// importFile reads a dotenv file to import, warning about each reference to a variable it does not assign,
+// which is replaced by its default value or the empty string.
+func importFile(path string) (env.Env, error) {
+	var unresolved []string
+
+	variables, err := dotenv.Read(path, func(key string) (string, bool) {
+		if !slices.Contains(unresolved, key) {
+			unresolved = append(unresolved, key)
+		}
+
+		return "", false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range unresolved {
+		fmt.Fprintf(os.Stderr, "Warning: %q references %q, which it does not assign: importing its default or empty\n",
+			path, key)
+	}
+
+	return variables, nil
+}
+
+// importProfiles returns the profiles to import with the given names and variables.
+// With a base, the variables common to all are extracted into a base profile with the extends,
+// which the other profiles extend instead, unless there are none.
+func importProfiles(names []string, envs []env.Env, extends []string, base string) []imported {
+	imports := make([]imported, 0, len(names)+1)
+
+	shared := env.Env{}
+	if base != "" {
+		shared = common(envs)
+	}
+
+	if len(shared) > 0 {
+		imports = append(imports, imported{name: base, extends: extends, env: shared})
+		extends = []string{base}
+	}
+
+	for i, name := range names {
+		variables := maps.Clone(envs[i])
+
+		maps.DeleteFunc(variables, func(key, _ string) bool { return shared.Exists(key) })
+
+		imports = append(imports, imported{name: name, extends: extends, env: variables})
+	}
+
+	return imports
+}
+
+// importProfile adds the profile with its extends and variables.
+func importProfile(doc edit.Document, profile imported) error {
+	if err := doc.AddProfile(profile.name); err != nil {
+		return err
+	}
+
+	if err := doc.SetExtends(profile.name, profile.extends); err != nil {
+		return err
+	}
+
+	for _, key := range profile.env.Keys() {
+		if err := doc.Set(profile.name, key, profile.env[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importName derives the profile name from the name of a dotenv file, such as '.env.staging' or 'staging.env'.
+func importName(path string) (string, error) {
+	base := filepath.Base(path)
+
+	for _, name := range []string{strings.TrimPrefix(base, ".env."), strings.TrimSuffix(base, ".env")} {
+		if name != base && name != "" && !strings.HasPrefix(name, ".") {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot derive a profile name from %q, use --as", path)
+}
+
+// common returns the variables with the same value in all environments.
+func common(envs []env.Env) env.Env {
+	shared := env.Env{}
+
+	for key, value := range envs[0] {
+		if !slices.ContainsFunc(envs[1:], func(other env.Env) bool {
+			v, ok := other[key]
+
+			return !ok || v != value
+		}) {
+			shared[key] = value
+		}
+	}
+
+	return shared
+}
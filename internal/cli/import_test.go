@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+func TestImportName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: ".env.staging", want: "staging"},
+		{path: "config/.env.prod", want: "prod"},
+		{path: "staging.env", want: "staging"},
+		{path: ".env.dev.local", want: "dev.local"},
+		{path: ".env", want: ""},
+		{path: ".env.", want: ""},
+		{path: "staging", want: ""},
+		{path: ".staging.env", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := importName(test.path)
+
+			switch {
+			case test.want == "" && err == nil:
+				t.Errorf("importName(%q) = %q, want an error", test.path, got)
+			case test.want != "" && err != nil:
+				t.Errorf("importName(%q) returned error: %v", test.path, err)
+			case got != test.want:
+				t.Errorf("importName(%q) = %q, want %q", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCommon(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		envs []env.Env
+		want env.Env
+	}{
+		{
+			name: "same values",
+			envs: []env.Env{{"A": "1", "B": "2"}, {"A": "1", "B": "3"}, {"A": "1", "C": "2"}},
+			want: env.Env{"A": "1"},
+		},
+		{
+			name: "missing in one",
+			envs: []env.Env{{"A": "1"}, {"A": "1"}, {"B": "1"}},
+			want: env.Env{},
+		},
+		{
+			name: "empty values",
+			envs: []env.Env{{"A": ""}, {"A": ""}},
+			want: env.Env{"A": ""},
+		},
+		{
+			name: "empty and missing",
+			envs: []env.Env{{"A": ""}, {}},
+			want: env.Env{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := common(test.envs); !maps.Equal(got, test.want) {
+				t.Errorf("common(%q) = %q, want %q", test.envs, got, test.want)
+			}
+		})
+	}
+}
+
+func TestImportProfiles(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"dev", "prod"}
+
+	tests := []struct {
+		name string
+		envs []env.Env
+		base string
+		want []imported
+	}{
+		{
+			name: "without dedupe",
+			envs: []env.Env{{"A": "1", "B": "2"}, {"A": "1", "B": "3"}},
+			want: []imported{
+				{name: "dev", extends: []string{"root"}, env: env.Env{"A": "1", "B": "2"}},
+				{name: "prod", extends: []string{"root"}, env: env.Env{"A": "1", "B": "3"}},
+			},
+		},
+		{
+			name: "dedupe",
+			envs: []env.Env{{"A": "1", "B": "2"}, {"A": "1", "B": "3"}},
+			base: "common",
+			want: []imported{
+				{name: "common", extends: []string{"root"}, env: env.Env{"A": "1"}},
+				{name: "dev", extends: []string{"common"}, env: env.Env{"B": "2"}},
+				{name: "prod", extends: []string{"common"}, env: env.Env{"B": "3"}},
+			},
+		},
+		{
+			name: "dedupe everything",
+			envs: []env.Env{{"A": "1"}, {"A": "1"}},
+			base: "common",
+			want: []imported{
+				{name: "common", extends: []string{"root"}, env: env.Env{"A": "1"}},
+				{name: "dev", extends: []string{"common"}, env: env.Env{}},
+				{name: "prod", extends: []string{"common"}, env: env.Env{}},
+			},
+		},
+		{
+			name: "nothing to dedupe",
+			envs: []env.Env{{"A": "1"}, {"A": "2"}},
+			base: "common",
+			want: []imported{
+				{name: "dev", extends: []string{"root"}, env: env.Env{"A": "1"}},
+				{name: "prod", extends: []string{"root"}, env: env.Env{"A": "2"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			envs := make([]env.Env, 0, len(test.envs))
+			for _, variables := range test.envs {
+				envs = append(envs, maps.Clone(variables))
+			}
+
+			got := importProfiles(names, envs, []string{"root"}, test.base)
+
+			if !slices.EqualFunc(got, test.want, func(a, b imported) bool {
+				return a.name == b.name && slices.Equal(a.extends, b.extends) && maps.Equal(a.env, b.env)
+			}) {
+				t.Errorf("importProfiles() = %+v, want %+v", got, test.want)
+			}
+
+			for i, variables := range envs {
+				if !maps.Equal(variables, test.envs[i]) {
+					t.Errorf("importProfiles() changed the variables of %q to %q", names[i], variables)
+				}
+			}
+		})
+	}
+}
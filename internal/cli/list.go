@@ -2,12 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
 
 	"github.com/idelchi/envprof/internal/environment"
+	"github.com/idelchi/envprof/internal/step"
 )
 
 // List returns the cobra command for listing profiles and their variables.
@@ -48,18 +50,34 @@ func List(options *Options) *cobra.Command {
 			return nil
 		},
 		RunE: func(_ *cobra.Command, args []string) error {
-			profiles, profile, steps, err := LoadPlan(options)
+			// The plan alone does not use the profile, so it is not recorded in the audit log.
+			ep, profiles, profile, steps, err := loadPlan(options)
 			if err != nil {
 				return err
 			}
 
 			if dry {
+				// Show the dotenv files as read, relative paths being resolved against the working directory.
+				for i := range steps {
+					if steps[i].Kind != step.DotEnv || filepath.IsAbs(steps[i].Name) {
+						continue
+					}
+
+					if steps[i].Name, err = filepath.Abs(steps[i].Name); err != nil {
+						return err
+					}
+				}
+
 				//nolint:forbidigo	// Command prints out to the console.
 				fmt.Println(steps.Table())
 
 				return nil
 			}
 
+			if err := recordUse(ep, options, profile); err != nil {
+				return err
+			}
+
 			env, err := profiles.Environment(profile, steps)
 			if err != nil {
 				return err
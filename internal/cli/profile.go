@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/edit"
+	"github.com/idelchi/envprof/internal/envprof"
+)
+
+// Profile returns the cobra command for adding, copying, renaming and deleting profiles in the config file.
+func Profile(options *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Add, copy, rename and delete profiles in the config file",
+		Long: heredoc.Doc(`
+			Add, copy, rename and delete profiles in place, leaving the rest of the file untouched, comments included.
+		`),
+		Example: heredoc.Doc(`
+			# Add an empty profile
+			envprof profile add staging
+
+			# Copy a profile
+			envprof profile copy prod prod-eu
+
+			# Rename a profile, updating the profiles extending it
+			envprof profile rename dev local
+
+			# Delete a profile
+			envprof profile delete old
+		`),
+		Args: cobra.NoArgs,
+		RunE: UnknownSubcommandAction,
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "add NAME",
+			Short: "Add an empty profile",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(_ *cobra.Command, args []string) error {
+				if err := newProfile(options, args[0]); err != nil {
+					return err
+				}
+
+				return Edit(options, func(doc edit.Document) error { return doc.AddProfile(args[0]) })
+			},
+		},
+		&cobra.Command{
+			Use:   "copy FROM TO",
+			Short: "Copy a profile under a new name, re-encrypting its encrypted values",
+			Args:  cobra.ExactArgs(2), //nolint:mnd	// Source and target.
+			RunE: func(_ *cobra.Command, args []string) error {
+				if err := newProfile(options, args[1]); err != nil {
+					return err
+				}
+
+				profiles, err := LoadProfiles(options)
+				if err != nil {
+					return err
+				}
+
+				prof, err := profiles.Get(args[0])
+				if err != nil {
+					return err
+				}
+
+				return Edit(options, func(doc edit.Document) error {
+					if err := doc.CopyProfile(args[0], args[1]); err != nil {
+						return err
+					}
+
+					return rebind(doc, prof, args[0], args[1])
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "rename FROM TO",
+			Short: "Rename a profile, updating the extends of other profiles referring to it",
+			Args:  cobra.ExactArgs(2), //nolint:mnd	// Source and target.
+			RunE: func(_ *cobra.Command, args []string) error {
+				if err := newProfile(options, args[1]); err != nil {
+					return err
+				}
+
+				profiles, err := LoadProfiles(options)
+				if err != nil {
+					return err
+				}
+
+				prof, err := profiles.Get(args[0])
+				if err != nil {
+					return err
+				}
+
+				return Edit(options, func(doc edit.Document) error {
+					if err := edit.Rename(doc, profiles.Dependents(args[0]), args[0], args[1]); err != nil {
+						return err
+					}
+
+					return rebind(doc, prof, args[0], args[1])
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "delete NAME",
+			Short: "Delete a profile, which must not be extended by other profiles",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(_ *cobra.Command, args []string) error {
+				profiles, err := LoadProfiles(options)
+				if err != nil {
+					return err
+				}
+
+				if dependents := profiles.Dependents(args[0]); len(dependents) > 0 {
+					return fmt.Errorf("profile %q is extended by %s, remove those extends first",
+						args[0], strings.Join(dependents, ", "))
+				}
+
+				return Edit(options, func(doc edit.Document) error { return doc.DeleteProfile(args[0]) })
+			},
+		},
+	)
+
+	return cmd
+}
+
+// newProfile checks that the name can be used for a new profile.
+func newProfile(options *Options, name string) error {
+	if name == "" || name == envprof.Reserved || strings.Contains(name, ",") {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+
+	profiles, err := LoadProfiles(options)
+	if err != nil {
+		return err
+	}
+
+	if profiles.Exists(name) {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+
+	return nil
+}
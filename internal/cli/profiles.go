@@ -25,7 +25,7 @@ func Profiles(options *Options) *cobra.Command {
 			# List all profiles
 			envprof profiles
 
-			# Highlight active profile
+			# Highlight active profile and explain why it was selected
 			envprof profiles -v
 		`),
 		Aliases: []string{"profs"},
@@ -48,7 +48,8 @@ func Profiles(options *Options) *cobra.Command {
 			}
 
 			// It's not important if the active profile is existing or not.
-			name, _ := envprof.GetOrDefault(options.Profile)
+			selection, selected := envprof.Select(options.Profile)
+			name := selection.Name
 
 			profiles := envprof.Profiles().Names()
 
@@ -71,6 +72,11 @@ func Profiles(options *Options) *cobra.Command {
 				fmt.Println(formatProfile(profile, options.Verbose, profile == name))
 			}
 
+			if options.Verbose && selected == nil {
+				//nolint:forbidigo	// Command prints out to the console.
+				fmt.Printf("\n%s\n", selection.Explain())
+			}
+
 			return nil
 		},
 	}
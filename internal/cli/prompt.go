@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/pkg/terminal"
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// Prompt returns the cobra command for printing a prompt segment with the active profiles.
+func Prompt(options *Options) *cobra.Command {
+	environment := env.FromEnv()
+
+	color := "auto"
+
+	cmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Print a prompt segment with the active profiles",
+		Long: heredoc.Doc(`
+			Print a short segment for shell prompts (e.g. starship), showing the profiles
+			of the active profile shells, innermost last (e.g. "dev>debug").
+
+			The segment is colored with the 'color' of the innermost profile.
+			Prints nothing outside of profile shells.
+		`),
+		Example: heredoc.Doc(`
+			# Print the prompt segment
+			envprof prompt
+
+			# Force colors when the output is captured by a prompt tool
+			envprof prompt --color always
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			stack := Stack(environment)
+			if len(stack) == 0 {
+				return nil
+			}
+
+			segment := strings.Join(stack, ">")
+
+			colored, err := UseColor(color, os.Stdout)
+			if err != nil {
+				return err
+			}
+
+			// The prompt must not fail if the config file is unavailable, it is only needed for the color.
+			if ep, err := LoadEnvProf(options); colored && err == nil {
+				segment = terminal.Color(ep.Profiles()[stack[len(stack)-1]].Color).Paint(segment)
+			}
+
+			//nolint:forbidigo	// Command prints out to the console.
+			fmt.Println(segment)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().StringVar(&color, "color", color, "Colorize the output (auto, always or never)")
+
+	return cmd
+}
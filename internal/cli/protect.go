@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/idelchi/envprof/internal/profiles"
+	"github.com/idelchi/envprof/internal/step"
+)
+
+// Confirm requires the user to confirm the use of each protected profile contributing to the plan,
+// by typing its name on the terminal. Confirmation is skipped with --allow-protected.
+func Confirm(options *Options, prof profiles.Profiles, steps step.Steps) error {
+	protections, err := prof.Protections(steps)
+	if err != nil || len(protections) == 0 || options.AllowProtected {
+		return err
+	}
+
+	lines := make([]string, 0, len(protections))
+
+	for _, protection := range protections {
+		line := fmt.Sprintf("profile %q is protected", protection.Name)
+		if protection.Message != "" {
+			line += ": " + protection.Message
+		}
+
+		lines = append(lines, line)
+	}
+
+	description := strings.Join(lines, "\n")
+
+	tty, err := openTerminal()
+	if err != nil {
+		return fmt.Errorf("%s\nconfirmation requires a terminal, pass --allow-protected to skip it", description)
+	}
+
+	defer tty.Close()
+
+	if _, err := fmt.Fprintln(tty, description); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(tty)
+
+	for _, protection := range protections {
+		if _, err := fmt.Fprintf(tty, "Type %q to continue: ", protection.Name); err != nil {
+			return err
+		}
+
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			return fmt.Errorf("reading confirmation: %w", err)
+		}
+
+		if strings.TrimSpace(answer) != protection.Name {
+			return fmt.Errorf("confirmation of profile %q failed, aborting", protection.Name)
+		}
+	}
+
+	return nil
+}
+
+// openTerminal opens the controlling terminal for reading and writing,
+// independent of redirections of the standard streams.
+func openTerminal() (*os.File, error) {
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+
+	return os.OpenFile(name, os.O_RDWR, 0)
+}
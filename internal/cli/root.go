@@ -19,6 +19,10 @@ type Options struct {
 	Verbose bool
 	// Overlay contains the profiles to overlay on top of the current profile.
 	Overlay []string
+	// Command is the name of the running subcommand, recorded in the audit log.
+	Command string
+	// AllowProtected skips the confirmation of protected profiles.
+	AllowProtected bool
 }
 
 // Execute runs the root command for the envprof CLI application.
@@ -73,6 +77,9 @@ func Execute(version string) error {
 		TraverseChildren: true,
 		SilenceUsage:     true,
 		RunE:             UnknownSubcommandAction,
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			options.Command = cmd.Name()
+		},
 	}
 
 	root.SetVersionTemplate("{{ .Version }}\n")
@@ -96,6 +103,8 @@ func Execute(version string) error {
 		BoolVarP(&options.Verbose, "verbose", "v", false, "Increase verbosity level")
 	root.Flags().
 		StringSliceVarP(&options.Overlay, "overlay", "o", nil, "Profiles to overlay on top of the current profile")
+	root.Flags().
+		BoolVar(&options.AllowProtected, "allow-protected", false, "Use protected profiles without confirmation")
 
 	root.AddCommand(
 		Path(options),
@@ -104,8 +113,23 @@ func Execute(version string) error {
 		Export(options),
 		Write(options),
 		Shell(options),
+		Status(options),
+		Prompt(options),
 		Exec(options),
+		Each(options),
 		Diff(options),
+		Compare(options),
+		Graph(options),
+		Audit(options),
+		Encrypt(options),
+		Decrypt(options),
+		Set(options),
+		Unset(options),
+		Profile(options),
+		Extends(options),
+		Import(options),
+		Convert(options),
+		Fmt(options),
 	)
 
 	if err := root.Execute(); err != nil {
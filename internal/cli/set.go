@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/envprof/internal/edit"
+)
+
+// Set returns the cobra command for setting env variables of a profile in the config file.
+func Set(options *Options) *cobra.Command {
+	var profile string
+
+	cmd := &cobra.Command{
+		Use:   "set KEY=VALUE [KEY=VALUE...]",
+		Short: "Set env variables of a profile in the config file",
+		Long: heredoc.Doc(`
+			Set env variables of a profile in place, leaving the rest of the file untouched, comments included.
+
+			Existing variables keep their position, new ones are added after the existing ones.
+			Values are written as strings.
+		`),
+		Example: heredoc.Doc(`
+			# Set variables of the 'dev' profile
+			envprof set HOST=localhost PORT=8080 --profile dev
+
+			# Set a variable of the default profile
+			envprof set LOG_LEVEL=debug
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			type assignment struct{ key, value string }
+
+			assignments := make([]assignment, 0, len(args))
+
+			for _, arg := range args {
+				key, value, ok := strings.Cut(arg, "=")
+				if !ok || key == "" {
+					return fmt.Errorf("invalid assignment %q, must be KEY=VALUE", arg)
+				}
+
+				assignments = append(assignments, assignment{key: key, value: value})
+			}
+
+			profile, err := EditedProfile(options, profile)
+			if err != nil {
+				return err
+			}
+
+			return Edit(options, func(doc edit.Document) error {
+				for _, assignment := range assignments {
+					if err := doc.Set(profile, assignment.key, assignment.value); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().
+		StringVarP(&profile, "profile", "p", "", "Profile to edit (defaults to the selected profile, ignoring default_when)")
+
+	return cmd
+}
+
+// Unset returns the cobra command for removing env variables of a profile from the config file.
+func Unset(options *Options) *cobra.Command {
+	var profile string
+
+	cmd := &cobra.Command{
+		Use:   "unset KEY [KEY...]",
+		Short: "Remove env variables of a profile from the config file",
+		Long: heredoc.Doc(`
+			Remove env variables of a profile in place, leaving the rest of the file untouched.
+
+			Only variables defined in the profile itself can be removed, not inherited ones.
+		`),
+		Example: heredoc.Doc(`
+			# Remove a variable of the 'dev' profile
+			envprof unset DEBUG --profile dev
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, keys []string) error {
+			profile, err := EditedProfile(options, profile)
+			if err != nil {
+				return err
+			}
+
+			return Edit(options, func(doc edit.Document) error {
+				for _, key := range keys {
+					if err := doc.Unset(profile, key); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().SortFlags = false
+
+	cmd.Flags().
+		StringVarP(&profile, "profile", "p", "", "Profile to edit (defaults to the selected profile, ignoring default_when)")
+
+	return cmd
+}
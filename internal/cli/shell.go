@@ -1,7 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
@@ -12,6 +16,8 @@ import (
 )
 
 // Shell returns the cobra command for entering a scoped shell with the active environment.
+//
+//nolint:funlen	// Long help text and flags.
 func Shell(options *Options) *cobra.Command {
 	environment := env.FromEnv()
 
@@ -19,6 +25,8 @@ func Shell(options *Options) *cobra.Command {
 		shell   = environment.GetAny("SHELL", "STARSHIP_SHELL")
 		isolate bool
 		path    bool
+		nest    bool
+		prompt  bool
 		envs    []string
 	)
 
@@ -29,6 +37,21 @@ func Shell(options *Options) *cobra.Command {
 			Launch a new shell with the selected profile's environment.
 
 			Customize shell and level of isolation with --shell, --isolate, and --path.
+
+			Inside a profile shell, another one can only be entered with --nest.
+			The nested shell layers the new profile on top of the active one,
+			tracking the profiles in ENVPROF_PROFILE_STACK (outermost first, comma-separated).
+			Use 'envprof status' to show the stack.
+
+			With --prompt, the shell's prompt is prefixed with the active profiles,
+			in the color of the profile. Supported for bash, zsh, fish, PowerShell and cmd,
+			other shells get a plain PS1.
+
+			The 'shell.init' commands and 'shell.aliases' of the profile are run and defined
+			at startup, after the user's own startup files.
+
+			If the profile has a 'ttl', the shell exits once it expires, with a warning shortly before.
+			The expiry time is available in ENVPROF_EXPIRES_AT.
 		`),
 		Example: heredoc.Doc(`
 			# Subshell with profile
@@ -42,28 +65,46 @@ func Shell(options *Options) *cobra.Command {
 
 			# Use a specific shell
 			envprof --profile dev shell --shell zsh
+
+			# Show the profile in the prompt
+			envprof --profile prod shell --prompt
+
+			# Layer 'debug' on top of the active profile
+			envprof --profile debug shell --nest
 		`),
 		Aliases: []string{"sh"},
 		Args:    cobra.NoArgs,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			if active := environment.Get("ENVPROF_ACTIVE_PROFILE"); environment.Exists(
 				"ENVPROF_ACTIVE_PROFILE",
-			) {
+			) && !nest {
 				return fmt.Errorf(
-					"already inside profile %q, nested profiles are not allowed, please exit first",
+					"already inside profile %q, please exit first or use --nest to layer profiles",
 					active,
 				)
 			}
 
-			profile, err := LoadProfile(options)
+			activation, err := Activate(options)
 			if err != nil {
 				return err
 			}
 
+			if err := activation.Record(nil); err != nil {
+				return err
+			}
+
+			profiles, name, profile := activation.Profiles, activation.Name, activation.Environment
+
 			if err = profile.Env.AddPair("ENVPROF_ACTIVE_PROFILE", profile.Name); err != nil {
 				return err
 			}
 
+			stack := append(Stack(environment), profile.Name)
+
+			if err = profile.Env.AddPair("ENVPROF_PROFILE_STACK", strings.Join(stack, ",")); err != nil {
+				return err
+			}
+
 			profile.Env = Merge(profile.Env, environment, isolate, path, envs)
 
 			if shell == "" {
@@ -74,13 +115,44 @@ func Shell(options *Options) *cobra.Command {
 			fmt.Printf(
 				"Entering shell %q with profile %q...\n",
 				file.New(shell).WithoutExtension().Base(),
-				profile.Name,
+				strings.Join(stack, " > "),
 			)
 
-			if err := terminal.Spawn(shell, profile.Env.AsSlice()); err != nil {
+			var setup terminal.Setup
+
+			if init := profiles[name].Shell; init != nil {
+				setup.Init = init.Init
+				setup.Aliases = init.Aliases
+			}
+
+			if prompt {
+				setup.Prompt = "(" + strings.Join(stack, ">") + ")"
+				setup.Color = terminal.Color(profiles[name].Color)
+			}
+
+			ctx := context.Background()
+
+			if activation.TTL > 0 {
+				var cancel context.CancelFunc
+
+				ctx, cancel = context.WithDeadline(ctx, activation.Expires)
+				defer cancel()
+
+				warning := time.AfterFunc(time.Until(activation.Expires)-min(time.Minute, activation.TTL/2), func() {
+					fmt.Fprintf(os.Stderr, "\nenvprof: profile %q expires at %s, the shell will exit\n",
+						name, activation.Expires.Format(time.TimeOnly))
+				})
+				defer warning.Stop()
+			}
+
+			if err := terminal.SpawnWith(ctx, shell, profile.Env.AsSlice(), setup); err != nil {
 				return err
 			}
 
+			if ctx.Err() != nil {
+				return fmt.Errorf("profile %q expired after %s, shell exited", name, activation.TTL)
+			}
+
 			return nil
 		},
 	}
@@ -91,8 +163,24 @@ func Shell(options *Options) *cobra.Command {
 		StringVarP(&shell, "shell", "s", shell, "Shell to launch (leave empty to auto-detect)")
 	cmd.Flags().BoolVarP(&isolate, "isolate", "i", false, "Isolate from parent environment")
 	cmd.Flags().BoolVarP(&path, "path", "p", false, "Include the current PATH in the environment")
+	cmd.Flags().BoolVarP(&prompt, "prompt", "P", false, "Show the active profiles in the shell prompt")
+	cmd.Flags().BoolVarP(&nest, "nest", "n", false, "Allow layering the profile on top of the active profile shell")
 	cmd.Flags().
 		StringSliceVarP(&envs, "env", "e", nil, "Passthrough environment variables (combined with --isolate)")
 
 	return cmd
 }
+
+// Stack returns the profiles of the nested profile shells from ENVPROF_PROFILE_STACK, outermost first.
+// Shells started before the stack was tracked are represented by ENVPROF_ACTIVE_PROFILE alone.
+func Stack(environment env.Env) []string {
+	if stack := environment.Get("ENVPROF_PROFILE_STACK"); stack != "" {
+		return strings.Split(stack, ",")
+	}
+
+	if active := environment.Get("ENVPROF_ACTIVE_PROFILE"); active != "" {
+		return []string{active}
+	}
+
+	return nil
+}
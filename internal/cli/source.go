@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/idelchi/envprof/internal/audit"
+	"github.com/idelchi/envprof/internal/environment"
+	"github.com/idelchi/envprof/internal/envprof"
+	"github.com/idelchi/envprof/pkg/dotenv"
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// LoadSource resolves an environment from a source specification:
+//
+//   - [profile:]<name>: a profile of the loaded file, with overlays applied
+//   - dotenv:<path>: a dotenv file
+//   - environ: the environment of the current process
+//   - file:<path>[#<profile>]: a profile of another file with overlays applied, the default profile if omitted
+//   - git:<rev>[#<profile>]: a profile of the loaded file at a git revision with overlays applied,
+//     the default profile if omitted
+func LoadSource(options *Options, spec string) (environment.Environment, error) {
+	kind, value, found := strings.Cut(spec, ":")
+	if !found {
+		kind, value = "profile", spec
+	}
+
+	switch kind {
+	case "profile":
+		opts := *options
+		opts.Profile = value
+
+		return LoadProfile(&opts)
+	case "dotenv":
+		env, err := dotenv.Read(value, nil)
+		if err != nil {
+			return environment.Environment{}, err
+		}
+
+		return environment.Environment{Name: spec, Env: env}, nil
+	case "environ":
+		return environment.Environment{Name: spec, Env: env.FromEnv()}, nil
+	case "file":
+		path, profile, _ := strings.Cut(value, "#")
+
+		opts := *options
+		opts.EnvProf, opts.Profile = []string{path}, profile
+
+		return LoadProfile(&opts)
+	case "git":
+		rev, profile, _ := strings.Cut(value, "#")
+
+		return loadRevision(options, rev, profile)
+	default:
+		return environment.Environment{}, fmt.Errorf(
+			"source %q: unsupported kind %q, must be one of profile, dotenv, environ, file or git",
+			spec,
+			kind,
+		)
+	}
+}
+
+// loadRevision resolves a profile from the loaded file as of a git revision, using the local git binary.
+// The revision is checked out into a temporary directory, so that dotenv extends are read as of the revision too.
+func loadRevision(options *Options, rev, profile string) (environment.Environment, error) {
+	ep, err := EnvProf(options)
+	if err != nil {
+		return environment.Environment{}, err
+	}
+
+	worktree, checkout, err := gitCheckout(ep.File().Dir(), rev)
+	if checkout != "" {
+		defer os.RemoveAll(filepath.Dir(checkout))
+	}
+
+	if err != nil {
+		return environment.Environment{}, fmt.Errorf("checking out revision %q: %w", rev, err)
+	}
+
+	path, err := filepath.Abs(ep.File().Path())
+	if err != nil {
+		return environment.Environment{}, err
+	}
+
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	rel, err := filepath.Rel(worktree, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return environment.Environment{}, fmt.Errorf("%q is not within the git working tree %q", ep.File(), worktree)
+	}
+
+	data, err := os.ReadFile(filepath.Join(checkout, rel))
+	if err != nil {
+		return environment.Environment{}, fmt.Errorf("reading %q at revision %q: %w", ep.File(), rev, err)
+	}
+
+	ep.SetCheckout(worktree, checkout)
+
+	if err := ep.LoadBytes(data); err != nil {
+		return environment.Environment{}, fmt.Errorf("loading %q at revision %q: %w", ep.File(), rev, err)
+	}
+
+	return resolve(options, ep, profile, options.Overlay...)
+}
+
+// gitCheckout checks out the revision of the git repository containing dir into a temporary directory,
+// without touching the repository's own index or working tree.
+// It returns the top-level directory of the working tree and the checkout,
+// which is to be removed along with its parent directory.
+func gitCheckout(dir, rev string) (worktree, checkout string, err error) {
+	// Verifying the revision first keeps it from being taken as an option by the commands below.
+	commit, err := git(dir, nil, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", "", err
+	}
+
+	worktree, err = git(dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", "", err
+	}
+
+	worktree = filepath.FromSlash(worktree)
+
+	tmp, err := os.MkdirTemp("", "envprof-git-*")
+	if err != nil {
+		return "", "", err
+	}
+
+	checkout = filepath.Join(tmp, "checkout")
+	index := []string{"GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}
+
+	if _, err := git(worktree, index, "read-tree", commit); err != nil {
+		return worktree, checkout, err
+	}
+
+	prefix := "--prefix=" + checkout + string(filepath.Separator)
+
+	if _, err := git(worktree, index, "checkout-index", "--all", prefix); err != nil {
+		return worktree, checkout, err
+	}
+
+	return worktree, checkout, nil
+}
+
+// git runs git in dir with the additional environment variables, returning its trimmed output.
+func git(dir string, env []string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	//nolint:gosec	// The revision is user input by design.
+	cmd := exec.CommandContext(context.Background(), "git", append([]string{"-C", dir}, args...)...)
+
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// resolve returns the environment of the given (or default) profile of a loaded file with the overlays applied,
+// recording its use in the audit log.
+func resolve(options *Options, ep *envprof.EnvProf, name string, overlays ...string) (environment.Environment, error) {
+	name, err := ep.GetOrDefault(name)
+	if err != nil {
+		return environment.Environment{}, err
+	}
+
+	if err := Record(ep, audit.Entry{Profile: name, Overlays: overlays, Command: options.Command}); err != nil {
+		return environment.Environment{}, err
+	}
+
+	profiles := ep.Profiles()
+
+	steps, err := profiles.Plan(name, overlays...)
+	if err != nil {
+		return environment.Environment{}, err
+	}
+
+	return profiles.Environment(name, steps)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// Status returns the cobra command for showing the active profile shells.
+func Status(options *Options) *cobra.Command {
+	environment := env.FromEnv()
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the stack of active profile shells",
+		Long: heredoc.Doc(`
+			Show the config file, and the stack of profile shells entered with 'shell' (outermost first).
+
+			For each level, lists the keys it introduced, and the keys it overrides from the levels below.
+			Keys are resolved from the current config file.
+			For time-limited profiles, shows when the shell expires.
+		`),
+		Example: heredoc.Doc(`
+			# Show the active profile shells
+			envprof status
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ep, err := LoadEnvProf(options)
+			if err != nil {
+				return err
+			}
+
+			var builder strings.Builder
+
+			fmt.Fprintf(&builder, "Config file: %s\n", ep.File())
+
+			stack := Stack(environment)
+			if len(stack) == 0 {
+				builder.WriteString("Not inside a profile shell.\n")
+
+				//nolint:forbidigo	// Command prints out to the console.
+				fmt.Print(builder.String())
+
+				return nil
+			}
+
+			fmt.Fprintf(&builder, "Stack: %s\n", strings.Join(stack, " > "))
+
+			if expires, err := time.Parse(time.RFC3339, environment.Get(Expires)); err == nil {
+				fmt.Fprintf(&builder, "Expires: %s (in %s)\n",
+					expires.Local().Format(time.DateTime), time.Until(expires).Round(time.Second))
+			}
+
+			builder.WriteString("\n")
+
+			//nolint:mnd 	// minwidth, tabwidth, padding, padchar, flags
+			writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+
+			_, _ = fmt.Fprintln(writer, "LEVEL\tPROFILE\tKEYS")
+
+			layered := make(env.Env)
+
+			for level, name := range stack {
+				profile, err := resolve(options, ep, name)
+				if err != nil {
+					_, _ = fmt.Fprintf(writer, "%d\t%s\t<%v>\n", level+1, name, err)
+
+					continue
+				}
+
+				var added, overridden []string
+
+				for _, key := range profile.Env.Keys() {
+					value := profile.Env.Get(key)
+
+					switch {
+					case !layered.Exists(key):
+						added = append(added, key)
+					case layered.Get(key) != value:
+						overridden = append(overridden, key)
+					}
+
+					layered[key] = value
+				}
+
+				keys := strings.Join(added, ", ")
+				if len(overridden) > 0 {
+					keys += fmt.Sprintf(" (overrides: %s)", strings.Join(overridden, ", "))
+				}
+
+				_, _ = fmt.Fprintf(writer, "%d\t%s\t%s\n", level+1, name, strings.TrimSpace(keys))
+			}
+
+			_ = writer.Flush()
+
+			//nolint:forbidigo	// Command prints out to the console.
+			fmt.Print(builder.String())
+
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -3,27 +3,50 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
 
+	"github.com/idelchi/envprof/internal/audit"
 	"github.com/idelchi/envprof/internal/environment"
+	"github.com/idelchi/envprof/internal/profile"
 	"github.com/idelchi/godyl/pkg/path/file"
 )
 
 // Write defines the command for writing profile variables to one or multiple dotenv files.
 func Write(options *Options) *cobra.Command {
-	var all bool
+	var (
+		all    bool
+		check  bool
+		format string
+		mode   string
+		write  environment.WriteOptions
+	)
 
 	cmd := &cobra.Command{
 		Use:   "write [file]",
 		Short: "Write profile variables to dotenv files",
-		Long: heredoc.Doc(`
-			Write variables to dotenv files.
+		Long: heredoc.Docf(`
+			Write variables to dotenv files, or other formats.
 
 			Files are written out as <profile>.env, unless set in the profile configuration file, or
 			overridden with the [file] argument.
-		`),
+
+			The format is taken from --format, the 'output_format' of the profile,
+			or inferred from the extension of the output file (.json, .yaml/.yml, .sh), defaulting to dotenv.
+
+			Supported formats: %s
+
+			Files are replaced atomically and only if their content changes.
+			Existing files lacking the '# Active profile' header are skipped, unless --force is given.
+			Formats without comments (json, github) carry no header, so existing files are only replaced with --force.
+			Files of the secret format or of profiles with encrypted values are only accessible by their owner.
+
+			With --check, nothing is written. Instead, the differences between the existing files
+			and the profiles are shown, failing if any file is out of date.
+		`, joinFormats()),
 		Example: heredoc.Doc(`
 			# Write 'dev' to dev.env
 			envprof --profile dev write
@@ -33,6 +56,15 @@ func Write(options *Options) *cobra.Command {
 
 			# Write all profiles to <profile>.env files
 			envprof write --all
+
+			# Write 'dev' as a Kubernetes ConfigMap manifest
+			envprof --profile dev write --format configmap dev.yaml
+
+			# Write 'prod' readable only by the owner, keeping a backup of the previous file
+			envprof --profile prod write --mode 0600 --backup
+
+			# Verify in CI that all written files are up to date
+			envprof write --all --check
 		`),
 		Aliases: []string{"w"},
 		Args:    cobra.RangeArgs(0, 1),
@@ -44,18 +76,70 @@ func Write(options *Options) *cobra.Command {
 				)
 			}
 
+			selected := environment.Format(format)
+			if err := selected.Valid(); err != nil {
+				return err
+			}
+
+			if mode != "" {
+				parsed, err := profile.ParseMode(mode)
+				if err != nil {
+					return err
+				}
+
+				write.Mode = parsed
+			}
+
 			environments, err := environments(all, options, args)
 			if err != nil {
 				return err
 			}
 
-			for _, environment := range environments {
-				if err := environment.Write(); err != nil {
+			if check {
+				return checkEnvironments(environments, selected)
+			}
+
+			counts := map[environment.Result]int{}
+
+			for _, env := range environments {
+				if selected != "" {
+					env.SetFormat(selected)
+				}
+
+				result, err := env.Write(write)
+				if err != nil {
 					return err
 				}
 
+				counts[result]++
+
 				//nolint:forbidigo		// Command prints out to the console.
-				fmt.Printf("Wrote profile %q to %q\n", environment.Name, environment.Output)
+				switch result {
+				case environment.Written:
+					fmt.Printf("Wrote profile %q to %q\n", env.Name, env.Output)
+				case environment.Unchanged:
+					fmt.Printf("Profile %q is unchanged in %q\n", env.Name, env.Output)
+				case environment.Skipped:
+					fmt.Printf(
+						"Skipped profile %q: %q may not have been written by envprof, use --force to overwrite\n",
+						env.Name,
+						env.Output,
+					)
+				}
+			}
+
+			if all {
+				//nolint:forbidigo		// Command prints out to the console.
+				fmt.Printf(
+					"%d written, %d unchanged, %d skipped\n",
+					counts[environment.Written],
+					counts[environment.Unchanged],
+					counts[environment.Skipped],
+				)
+			}
+
+			if skipped := counts[environment.Skipped]; skipped > 0 {
+				return fmt.Errorf("%d file(s) skipped", skipped)
 			}
 
 			return nil
@@ -65,6 +149,13 @@ func Write(options *Options) *cobra.Command {
 	cmd.Flags().SortFlags = false
 
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "Write all profiles, ignoring the active profile")
+	cmd.Flags().
+		StringVarP(&format, "format", "F", "", "Output format (leave empty to use the profile setting or infer)")
+	cmd.Flags().StringVarP(&mode, "mode", "m", "", "Octal file mode, overriding the profile setting (e.g. 0600)")
+	cmd.Flags().BoolVarP(&write.Backup, "backup", "b", false, "Keep a '.bak' copy of replaced files")
+	cmd.Flags().
+		BoolVar(&write.Force, "force", false, "Overwrite files lacking the envprof header, or in formats without one")
+	cmd.Flags().BoolVarP(&check, "check", "c", false, "Verify that the files are up to date, without writing")
 
 	return cmd
 }
@@ -73,16 +164,22 @@ func Write(options *Options) *cobra.Command {
 func environments(all bool, options *Options, args []string) (environments []environment.Environment, err error) {
 	switch {
 	case all:
-		profiles, err := LoadProfiles(options)
+		ep, err := LoadEnvProf(options)
 		if err != nil {
 			return nil, err
 		}
 
-		environments, err = profiles.Environments()
+		environments, err = ep.Profiles().Environments()
 		if err != nil {
 			return nil, err
 		}
 
+		for _, env := range environments {
+			if err := Record(ep, audit.Entry{Profile: env.Name, Command: options.Command}); err != nil {
+				return nil, err
+			}
+		}
+
 	default:
 		env, err := LoadProfile(options)
 		if err != nil {
@@ -98,3 +195,64 @@ func environments(all bool, options *Options, args []string) (environments []env
 
 	return environments, nil
 }
+
+// checkEnvironments verifies that the output files are up to date, printing the differences of those that are not.
+func checkEnvironments(environments []environment.Environment, format environment.Format) error {
+	outdated := 0
+
+	for _, env := range environments {
+		if format != "" {
+			env.SetFormat(format)
+		}
+
+		// A missing file with an empty environment would otherwise compare equal, as formatting only.
+		if _, err := os.Stat(env.Output.Path()); errors.Is(err, os.ErrNotExist) {
+			//nolint:forbidigo		// Command prints out to the console.
+			fmt.Printf("Profile %q is out of date in %q: missing\n", env.Name, env.Output)
+
+			outdated++
+
+			continue
+		}
+
+		ok, diff, err := env.Check()
+		if err != nil {
+			return err
+		}
+
+		//nolint:forbidigo		// Command prints out to the console.
+		switch {
+		case ok:
+			fmt.Printf("Profile %q is up to date in %q\n", env.Name, env.Output)
+		case diff.Equal():
+			fmt.Printf("Profile %q is out of date in %q: formatting differs\n", env.Name, env.Output)
+		default:
+			fmt.Printf("Profile %q is out of date in %q:\n", env.Name, env.Output)
+
+			if err := diff.Render(os.Stdout, env.Output.Path(), env.Name, environment.RenderOptions{}); err != nil {
+				return err
+			}
+		}
+
+		if !ok {
+			outdated++
+		}
+	}
+
+	if outdated > 0 {
+		return fmt.Errorf("%d file(s) out of date", outdated)
+	}
+
+	return nil
+}
+
+// joinFormats returns the supported output formats as a comma-separated list.
+func joinFormats() string {
+	formats := make([]string, 0, len(environment.Formats()))
+
+	for _, format := range environment.Formats() {
+		formats = append(formats, string(format))
+	}
+
+	return strings.Join(formats, ", ")
+}
@@ -0,0 +1,187 @@
+package edit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// JSON is the JSON format, only supported as the target of a conversion.
+const JSON Format = "json"
+
+// Convert converts the content of a profile file to another format.
+// The order of keys is kept, as are comments and the style of env sequences where the target supports them.
+// Env sequences become mappings in TOML, and JSON carries no comments.
+func Convert(data []byte, from, to Format) ([]byte, error) {
+	var (
+		root *node
+		err  error
+	)
+
+	switch from {
+	case YAML:
+		root, err = decodeYAML(data)
+	case TOML:
+		root, err = decodeTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported file format: %q", from)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch to {
+	case YAML:
+		return encodeYAML(root), nil
+	case TOML:
+		if err := envMappings(root); err != nil {
+			return nil, err
+		}
+
+		return encodeTOML(root)
+	case JSON:
+		return encodeJSON(root), nil
+	default:
+		return nil, fmt.Errorf("unsupported file format: %q", to)
+	}
+}
+
+// node is a value of a converted document, along with its comments.
+type node struct {
+	// value is a scalar, a sequence ([]*node) or a mapping (mapping).
+	value any
+	// comments are the comment lines above the value, without their '#'.
+	comments []string
+	// inline is the comment at the end of the line of the value, without its '#'.
+	inline string
+	// flow marks sequences and mappings written in flow style in YAML.
+	flow bool
+}
+
+// field is a key of a mapping with its value.
+type field struct {
+	key   string
+	value *node
+}
+
+// mapping is an ordered mapping.
+type mapping []field
+
+// set sets the value of the key, keeping the position of an existing key.
+func (m *mapping) set(key string, value *node) {
+	for i := range *m {
+		if (*m)[i].key == key {
+			(*m)[i].value = value
+
+			return
+		}
+	}
+
+	*m = append(*m, field{key: key, value: value})
+}
+
+// get returns the value of the key, or nil if there is none.
+func (m mapping) get(key string) *node {
+	for _, field := range m {
+		if field.key == key {
+			return field.value
+		}
+	}
+
+	return nil
+}
+
+// envMappings turns the env sequences of the profiles into mappings, keeping the comments of their items,
+// and null env values into empty strings, as TOML has neither.
+func envMappings(root *node) error {
+	profiles, _ := root.value.(mapping)
+
+	for _, profile := range profiles {
+		fields, _ := profile.value.value.(mapping)
+
+		env := fields.get("env")
+		if env == nil {
+			continue
+		}
+
+		switch value := env.value.(type) {
+		case mapping:
+			for _, field := range value {
+				switch {
+				case field.value.value == nil:
+					field.value.value = ""
+				case nested(field.value):
+					// Nested tables cannot be loaded from TOML env, use the JSON they are exported as instead.
+					data, err := json.Marshal(plain(field.value))
+					if err != nil {
+						return fmt.Errorf("profile %q: env %q: %w", profile.key, field.key, err)
+					}
+
+					field.value.value = string(data)
+				}
+			}
+		case []*node:
+			converted := make(mapping, 0, len(value))
+
+			for _, item := range value {
+				text, ok := item.value.(string)
+
+				name, content, found := strings.Cut(text, "=")
+				if !ok || !found {
+					return fmt.Errorf("profile %q: env: invalid entry %v, must be KEY=VALUE", profile.key, item.value)
+				}
+
+				converted = append(converted, field{
+					key:   name,
+					value: &node{value: content, comments: item.comments, inline: item.inline},
+				})
+			}
+
+			env.value, env.flow = converted, false
+		}
+	}
+
+	return nil
+}
+
+// nested reports whether the value is or contains a mapping.
+func nested(n *node) bool {
+	switch value := n.value.(type) {
+	case mapping:
+		return true
+	case []*node:
+		return slices.ContainsFunc(value, nested)
+	default:
+		return false
+	}
+}
+
+// plain returns the value as plain maps, slices and scalars.
+func plain(n *node) any {
+	switch value := n.value.(type) {
+	case mapping:
+		fields := make(map[string]any, len(value))
+
+		for _, field := range value {
+			fields[field.key] = plain(field.value)
+		}
+
+		return fields
+	case []*node:
+		items := make([]any, 0, len(value))
+
+		for _, item := range value {
+			items = append(items, plain(item))
+		}
+
+		return items
+	default:
+		return value
+	}
+}
+
+// errNull is returned for null values within TOML arrays and inline tables.
+var errNull = errors.New("null values cannot be represented in TOML")
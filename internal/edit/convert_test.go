@@ -0,0 +1,140 @@
+package edit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/idelchi/envprof/internal/edit"
+	"github.com/idelchi/envprof/internal/envprof"
+)
+
+// TestConvert converts the files in testdata/convert/<name>.<format> to every format,
+// comparing with <name>.to.<target>, and back again, comparing with <name>.roundtrip.<format>.
+// Every result must load as the same profiles and settings as the source.
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	sources, err := filepath.Glob(filepath.Join("testdata", "convert", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range sources {
+		name := filepath.Base(source)
+		if strings.Count(name, ".") != 1 {
+			continue
+		}
+
+		from := edit.Format(strings.TrimPrefix(filepath.Ext(name), "."))
+		stem := strings.TrimSuffix(source, filepath.Ext(source))
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, to := range []edit.Format{edit.YAML, edit.TOML, edit.JSON} {
+				converted, err := edit.Convert(data, from, to)
+				if err != nil {
+					t.Fatalf("converting to %s: %v", to, err)
+				}
+
+				golden(t, stem+".to."+string(to), converted)
+				same(t, data, from, converted, to)
+
+				if to == edit.JSON || to == from {
+					continue
+				}
+
+				back, err := edit.Convert(converted, to, from)
+				if err != nil {
+					t.Fatalf("converting back from %s: %v", to, err)
+				}
+
+				golden(t, stem+".roundtrip."+string(from), back)
+				same(t, data, from, back, from)
+			}
+		})
+	}
+}
+
+// golden compares the content with the golden file, or writes it with -update.
+func golden(t *testing.T, path string, content []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != string(want) {
+		t.Errorf("%s: got\n%s\nwant\n%s", path, content, want)
+	}
+}
+
+// same checks that the converted content loads as the same profiles and settings as the source.
+// JSON is loaded as YAML, of which it is a subset.
+func same(t *testing.T, data []byte, from edit.Format, converted []byte, to edit.Format) {
+	t.Helper()
+
+	if to == edit.JSON {
+		to = edit.YAML
+	}
+
+	profiles, settings, err := envprof.Unmarshal(data, envprof.Type(from))
+	if err != nil {
+		t.Fatalf("loading the source: %v", err)
+	}
+
+	convertedProfiles, convertedSettings, err := envprof.Unmarshal(converted, envprof.Type(to))
+	if err != nil {
+		t.Fatalf("loading the %s conversion: %v\n%s", to, err, converted)
+	}
+
+	if settings != convertedSettings {
+		t.Errorf("%s conversion: settings %+v, want %+v", to, convertedSettings, settings)
+	}
+
+	if err := profiles.Mismatch(convertedProfiles); err != nil {
+		t.Errorf("%s conversion: %v\n%s", to, err, converted)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		from, to edit.Format
+	}{
+		{name: "null in a TOML array", input: "dev:\n  pre: [echo, null]\n", from: edit.YAML, to: edit.TOML},
+		{name: "invalid env sequence entry", input: "dev:\n  env:\n    - A\n", from: edit.YAML, to: edit.TOML},
+		{name: "invalid YAML", input: "dev: [\n", from: edit.YAML, to: edit.TOML},
+		{name: "invalid TOML", input: "[dev\n", from: edit.TOML, to: edit.YAML},
+		{name: "unsupported source", input: "{}", from: edit.JSON, to: edit.YAML},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if converted, err := edit.Convert([]byte(test.input), test.from, test.to); err == nil {
+				t.Errorf("converting succeeded, want an error\n%s", converted)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package edit
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// decodeYAML decodes YAML content through its AST, keeping the order of keys, comments and flow styles.
+func decodeYAML(data []byte) (*node, error) {
+	file, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(file.Docs) == 0 || file.Docs[0].Body == nil {
+		return &node{value: mapping{}}, nil
+	}
+
+	return yamlDecoder{anchors: map[string]*node{}}.decode(file.Docs[0].Body)
+}
+
+// yamlDecoder decodes YAML nodes, resolving aliases to the anchors seen before.
+type yamlDecoder struct {
+	anchors map[string]*node
+}
+
+// decode decodes the YAML node.
+func (d yamlDecoder) decode(n ast.Node) (*node, error) {
+	switch n := n.(type) {
+	case nil:
+		return &node{}, nil
+	case *ast.MappingNode:
+		return d.mapping(n.Values, n.IsFlowStyle)
+	case *ast.MappingValueNode:
+		return d.mapping([]*ast.MappingValueNode{n}, false)
+	case *ast.SequenceNode:
+		items := make([]*node, 0, len(n.Values))
+
+		for i, value := range n.Values {
+			item, err := d.decode(value)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := item.value.(mapping); !ok {
+				item.inline = inlineComment(value.GetComment())
+			}
+
+			switch {
+			case i < len(n.ValueHeadComments) && n.ValueHeadComments[i] != nil:
+				item.comments = commentLines(n.ValueHeadComments[i])
+			case i == 0:
+				item.comments = commentLines(n.GetComment())
+			}
+
+			items = append(items, item)
+		}
+
+		return &node{value: items, flow: n.IsFlowStyle}, nil
+	case *ast.AnchorNode:
+		decoded, err := d.decode(n.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		d.anchors[scalar(n.Name)] = decoded
+
+		return decoded, nil
+	case *ast.AliasNode:
+		anchor, ok := d.anchors[scalar(n.Value)]
+		if !ok {
+			return nil, fmt.Errorf("unknown alias %q", scalar(n.Value))
+		}
+
+		alias := *anchor
+
+		return &alias, nil
+	case *ast.TagNode:
+		switch n.Value.(type) {
+		case *ast.MappingNode, *ast.MappingValueNode, *ast.SequenceNode:
+			return d.decode(n.Value)
+		}
+	}
+
+	var value any
+
+	if err := yaml.NodeToValue(n, &value); err != nil {
+		return nil, err
+	}
+
+	return &node{value: value}, nil
+}
+
+// mapping decodes the values of a mapping, applying merge keys.
+func (d yamlDecoder) mapping(values []*ast.MappingValueNode, flow bool) (*node, error) {
+	var (
+		fields   mapping
+		merged   []mapping
+		explicit = map[string]bool{}
+	)
+
+	for _, value := range values {
+		decoded, err := d.decode(value.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := value.Key.(*ast.MergeKeyNode); ok {
+			switch merge := decoded.value.(type) {
+			case mapping:
+				merged = append(merged, merge)
+			case []*node:
+				for _, item := range merge {
+					if fields, ok := item.value.(mapping); ok {
+						merged = append(merged, fields)
+					}
+				}
+			}
+
+			continue
+		}
+
+		var key any
+
+		if err := yaml.NodeToValue(value.Key, &key); err != nil {
+			return nil, err
+		}
+
+		decoded.comments = commentLines(value.GetComment())
+
+		// Comments after a key with a nested block belong to the key, otherwise to the value.
+		switch decoded.value.(type) {
+		case mapping, []*node:
+			if decoded.flow {
+				decoded.inline = inlineComment(value.Value.GetComment())
+			} else {
+				decoded.inline = inlineComment(value.Key.GetComment())
+			}
+		default:
+			decoded.inline = inlineComment(value.Value.GetComment())
+		}
+
+		name := fmt.Sprint(key)
+		explicit[name] = true
+
+		fields.set(name, decoded)
+	}
+
+	for _, merge := range merged {
+		for _, field := range merge {
+			if !explicit[field.key] && fields.get(field.key) == nil {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	if fields == nil {
+		fields = mapping{}
+	}
+
+	return &node{value: fields, flow: flow}, nil
+}
+
+// commentLines returns the lines of the comment, without their '#'.
+func commentLines(group *ast.CommentGroupNode) []string {
+	if group == nil {
+		return nil
+	}
+
+	lines := make([]string, 0, len(group.Comments))
+
+	for _, comment := range group.Comments {
+		lines = append(lines, comment.Token.Value)
+	}
+
+	return lines
+}
+
+// inlineComment returns the comment as a single line, without its '#'.
+func inlineComment(group *ast.CommentGroupNode) string {
+	return strings.Join(commentLines(group), " ")
+}
+
+// decodeTOML decodes TOML content, keeping the order of keys and the comments found line by line.
+func decodeTOML(data []byte) (*node, error) {
+	var decoded map[string]any
+
+	metadata, err := toml.Decode(string(data), &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := (&tomlDocument{lines: split(data)}).comments()
+	root := &node{value: mapping{}}
+
+	for _, key := range metadata.Keys() {
+		value, ok := valueAt(decoded, key)
+		if !ok {
+			continue
+		}
+
+		child := tomlNode(value)
+		if _, ok := value.(map[string]any); ok {
+			// The keys of tables follow, in order.
+			child.value = mapping{}
+		}
+
+		comment := comments[strings.Join(key, "\x00")]
+		child.comments, child.inline = comment.lines, comment.inline
+
+		root.insert(key, child)
+	}
+
+	return root, nil
+}
+
+// insert sets the value at the path below the mapping node, creating the mappings on the way.
+// Existing mappings are kept, taking over the comments of the value.
+func (n *node) insert(path []string, value *node) {
+	fields, ok := n.value.(mapping)
+	if !ok {
+		return
+	}
+
+	existing := fields.get(path[0])
+
+	switch {
+	case len(path) > 1 && existing == nil:
+		existing = &node{value: mapping{}}
+		fields.set(path[0], existing)
+
+		// The comments above a table header belong to its outermost implicit parent table.
+		if isMapping(value) {
+			existing.comments, value.comments = value.comments, nil
+		}
+	case len(path) > 1:
+	case existing != nil && isMapping(existing) && isMapping(value):
+		existing.comments, existing.inline = value.comments, value.inline
+	default:
+		fields.set(path[0], value)
+	}
+
+	n.value = fields
+
+	if len(path) > 1 {
+		existing.insert(path[1:], value)
+	}
+}
+
+// isMapping reports whether the node is a mapping.
+func isMapping(n *node) bool {
+	_, ok := n.value.(mapping)
+
+	return ok
+}
+
+// valueAt returns the decoded TOML value at the key, if it is within tables.
+func valueAt(decoded map[string]any, key []string) (any, bool) {
+	var value any = decoded
+
+	for _, part := range key {
+		table, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if value, ok = table[part]; !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// tomlNode converts a decoded TOML value, sorting the keys of tables.
+func tomlNode(value any) *node {
+	switch value := value.(type) {
+	case map[string]any:
+		fields := make(mapping, 0, len(value))
+
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			fields = append(fields, field{key: key, value: tomlNode(value[key])})
+		}
+
+		return &node{value: fields}
+	case []map[string]any:
+		items := make([]*node, 0, len(value))
+
+		for _, item := range value {
+			items = append(items, tomlNode(item))
+		}
+
+		return &node{value: items}
+	case []any:
+		items := make([]*node, 0, len(value))
+
+		for _, item := range value {
+			items = append(items, tomlNode(item))
+		}
+
+		return &node{value: items}
+	default:
+		return &node{value: value}
+	}
+}
+
+// tomlComment are the comments of a table or key.
+type tomlComment struct {
+	lines  []string
+	inline string
+}
+
+// comments returns the comments of the tables and keys, by their full key joined with NUL:
+// the comment lines directly above them, and the comment at the end of single-line values.
+func (d *tomlDocument) comments() map[string]tomlComment {
+	comments := map[string]tomlComment{}
+
+	above := func(line int) []string {
+		first := line
+
+		for first > 0 && strings.HasPrefix(strings.TrimSpace(d.lines[first-1]), "#") {
+			first--
+		}
+
+		lines := make([]string, 0, line-first)
+
+		for _, comment := range d.lines[first:line] {
+			lines = append(lines, strings.TrimPrefix(strings.TrimSpace(comment), "#"))
+		}
+
+		return lines
+	}
+
+	trailing := func(rest string) string {
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "#") {
+			return ""
+		}
+
+		return strings.TrimPrefix(rest, "#")
+	}
+
+	scan := d.scan()
+
+	for _, table := range scan.tables {
+		header := strings.TrimSpace(d.lines[table.header])
+
+		_, rest, _ := parseKeyUntil(strings.TrimLeft(header, "["), ']')
+
+		comments[strings.Join(table.key, "\x00")] = tomlComment{
+			lines:  above(table.header),
+			inline: trailing(strings.TrimLeft(rest, "]")),
+		}
+	}
+
+	for _, assignment := range scan.assignments {
+		comment := tomlComment{lines: above(assignment.line)}
+
+		if assignment.scalar {
+			comment.inline = trailing(d.lines[assignment.line][assignment.end:])
+		}
+
+		comments[strings.Join(assignment.key, "\x00")] = comment
+	}
+
+	return comments
+}
@@ -0,0 +1,5 @@
+// Package edit modifies profile files in place, preserving comments, ordering and formatting
+// of the parts that are not edited.
+// YAML files are edited through the go-yaml AST, TOML files line by line.
+// Files can also be converted between formats, keeping the order of keys and comments.
+package edit
@@ -0,0 +1,153 @@
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Format is the format of a profile file.
+type Format string
+
+const (
+	// YAML is the YAML format.
+	YAML Format = "yaml"
+	// TOML is the TOML format.
+	TOML Format = "toml"
+)
+
+var (
+	// ErrNotFound is returned when a profile or env variable does not exist in the file.
+	ErrNotFound = errors.New("not found")
+	// ErrUnsupported is returned for values that cannot be edited, such as lists or multi-line strings.
+	ErrUnsupported = errors.New("only single-line scalar values can be edited")
+)
+
+// Document is a profile file being edited.
+// Only the edited parts change, everything else is kept as it was, including comments.
+type Document interface {
+	// Value returns the value of the env variable of the profile, which must be a scalar.
+	Value(profile, key string) (string, error)
+	// Replace replaces the value of an existing env variable of the profile with a string.
+	Replace(profile, key, value string) error
+	// Set sets the env variable of the profile to a string, adding it after the existing ones if needed.
+	Set(profile, key, value string) error
+	// Unset removes the env variable from the profile, along with the comments directly above it.
+	Unset(profile, key string) error
+	// Extends returns the extends entries of the profile.
+	Extends(profile string) ([]string, error)
+	// SetExtends replaces the extends entries of the profile, removing them if empty.
+	SetExtends(profile string, extends []string) error
+	// AddProfile adds an empty profile at the end.
+	AddProfile(name string) error
+	// CopyProfile adds a copy of the profile under a new name, which is never the default profile.
+	CopyProfile(from, to string) error
+	// RenameProfile renames the profile, without updating references to it.
+	RenameProfile(from, to string) error
+	// DeleteProfile removes the profile, along with the comments directly above it.
+	DeleteProfile(name string) error
+	// Bytes returns the edited content.
+	Bytes() []byte
+}
+
+// Parse parses the content of a profile file for editing.
+func Parse(data []byte, format Format) (Document, error) {
+	switch format {
+	case YAML:
+		return parseYAML(data)
+	case TOML:
+		return parseTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported file format: %q", format)
+	}
+}
+
+// AddExtends appends the entries to the extends of the profile, skipping those already present.
+func AddExtends(doc Document, profile string, entries ...string) error {
+	extends, err := doc.Extends(profile)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !slices.Contains(extends, entry) {
+			extends = append(extends, entry)
+		}
+	}
+
+	return doc.SetExtends(profile, extends)
+}
+
+// RemoveExtends removes the entries from the extends of the profile.
+// Entries match with or without the "profile:" prefix.
+func RemoveExtends(doc Document, profile string, entries ...string) error {
+	extends, err := doc.Extends(profile)
+	if err != nil {
+		return err
+	}
+
+	remaining := slices.DeleteFunc(slices.Clone(extends), func(extend string) bool {
+		return slices.ContainsFunc(entries, func(entry string) bool { return sameExtend(extend, entry) })
+	})
+
+	if len(remaining) == len(extends) {
+		return fmt.Errorf("profile %q: extends %v: %w", profile, entries, ErrNotFound)
+	}
+
+	return doc.SetExtends(profile, remaining)
+}
+
+// Rename renames the profile and updates the extends of the given profiles referring to it,
+// keeping the "profile:" prefix where used.
+func Rename(doc Document, profiles []string, from, to string) error {
+	if err := doc.RenameProfile(from, to); err != nil {
+		return err
+	}
+
+	for _, profile := range profiles {
+		if profile == from {
+			profile = to
+		}
+
+		extends, err := doc.Extends(profile)
+		if err != nil {
+			return err
+		}
+
+		changed := false
+
+		for i, extend := range extends {
+			if sameExtend(extend, from) {
+				extends[i] = strings.TrimSuffix(extend, from) + to
+				changed = true
+			}
+		}
+
+		if changed {
+			if err := doc.SetExtends(profile, extends); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameExtend reports whether the extends entries refer to the same target, treating "name" as "profile:name".
+func sameExtend(a, b string) bool {
+	normalize := func(extend string) string {
+		if !strings.Contains(extend, ":") {
+			return "profile:" + extend
+		}
+
+		return extend
+	}
+
+	return normalize(a) == normalize(b)
+}
+
+// notFound returns an error for a missing env variable of a profile.
+func notFound(profile, key string) error {
+	return fmt.Errorf("profile %q: env %q: %w", profile, key, ErrNotFound)
+}
@@ -0,0 +1,329 @@
+package edit_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/idelchi/envprof/internal/edit"
+	"github.com/idelchi/envprof/internal/envprof"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata") //nolint:gochecknoglobals // Test flag.
+
+// operation is an edit applied to a document.
+type operation func(doc edit.Document) error
+
+// sequence applies the operations in order.
+func sequence(operations ...operation) operation {
+	return func(doc edit.Document) error {
+		for _, op := range operations {
+			if err := op(doc); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func set(profile, key, value string) operation {
+	return func(doc edit.Document) error { return doc.Set(profile, key, value) }
+}
+
+func unset(profile, key string) operation {
+	return func(doc edit.Document) error { return doc.Unset(profile, key) }
+}
+
+func addExtends(profile string, entries ...string) operation {
+	return func(doc edit.Document) error { return edit.AddExtends(doc, profile, entries...) }
+}
+
+func removeExtends(profile string, entries ...string) operation {
+	return func(doc edit.Document) error { return edit.RemoveExtends(doc, profile, entries...) }
+}
+
+// operations are the edits of the golden files in testdata/<format>/<name>.before.<format>,
+// by name, or by format and name where the formats differ.
+//
+//nolint:gochecknoglobals	// Test table.
+var operations = map[string]operation{
+	"set-replace": sequence(
+		set("dev", "HOST", "example.com"),
+		set("dev", "PORT", "9090"),
+		set("dev", "QUOTED", "it's new"),
+	),
+	"set-add": sequence(
+		set("dev", "B", "two words"),
+		set("prod", "C", "it's"),
+	),
+	"toml/set-add": sequence(
+		set("dev", "B", "two words"),
+		set("prod", "C", "it's"),
+		set("stage", "A", "1"),
+	),
+	"set-quoted-keys": sequence(
+		set("my profile", "A.B", "2"),
+		set("my profile", "C-D", "y"),
+		set("my profile", "E.F", "z"),
+	),
+	"set-flow": sequence(
+		set("dev", "A", "3"),
+		set("empty", "X", "1"),
+		set("none", "Y", "2"),
+	),
+	"set-sequence": sequence(
+		set("dev", "A", "x y"),
+		set("dev", "C", "3"),
+	),
+	"set-control": sequence(
+		set("dev", "A", "tab\there"),
+		set("dev", "B", "escape\x1b[0m"),
+		set("dev", "C", "line\r\nbreak"),
+	),
+	"set-new-env": sequence(
+		set("dev", "A", "1"),
+		set("prod", "B", "2"),
+	),
+	"unset": sequence(
+		unset("dev", "B"),
+		unset("dev", "C"),
+		unset("prod", "A"),
+	),
+	"yaml/unset": sequence(
+		unset("dev", "B"),
+		unset("dev", "C"),
+		unset("seq", "A"),
+	),
+	"extends": sequence(
+		addExtends("dev", "dotenv:dev.env"),
+		removeExtends("prod", "dotenv:.env"),
+		addExtends("stage", "base"),
+	),
+	"extends-remove": sequence(
+		removeExtends("dev", "base"),
+		removeExtends("prod", "base", "dotenv:.env"),
+	),
+	"profile-add": sequence(
+		func(doc edit.Document) error { return doc.AddProfile("new") },
+		func(doc edit.Document) error { return doc.AddProfile("with space") },
+	),
+	"profile-copy": func(doc edit.Document) error { return doc.CopyProfile("dev", "staging") },
+	"profile-rename": func(doc edit.Document) error {
+		return edit.Rename(doc, []string{"dev", "prod"}, "base", "common")
+	},
+	"profile-delete": func(doc edit.Document) error { return doc.DeleteProfile("dev") },
+	"crlf": sequence(
+		set("dev", "A", "new"),
+		set("dev", "D", "4"),
+		unset("dev", "B"),
+		addExtends("dev", "dotenv:.env"),
+		func(doc edit.Document) error { return doc.AddProfile("prod") },
+		set("prod", "E", "5"),
+	),
+	"no-trailing-newline": sequence(
+		set("dev", "B", "2"),
+		func(doc edit.Document) error { return doc.CopyProfile("dev", "prod") },
+	),
+}
+
+func TestGolden(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []edit.Format{edit.YAML, edit.TOML} {
+		befores, err := filepath.Glob(filepath.Join("testdata", string(format), "*.before."+string(format)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, before := range befores {
+			name := strings.TrimSuffix(filepath.Base(before), ".before."+string(format))
+
+			t.Run(string(format)+"/"+name, func(t *testing.T) {
+				t.Parallel()
+
+				op, ok := operations[string(format)+"/"+name]
+				if !ok {
+					if op, ok = operations[name]; !ok {
+						t.Fatalf("no operation for %q", before)
+					}
+				}
+
+				data, err := os.ReadFile(before)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				doc, err := edit.Parse(data, format)
+				if err != nil {
+					t.Fatalf("parsing: %v", err)
+				}
+
+				if err := op(doc); err != nil {
+					t.Fatalf("editing: %v", err)
+				}
+
+				after := strings.Replace(before, ".before.", ".after.", 1)
+
+				if *update {
+					if err := os.WriteFile(after, doc.Bytes(), 0o600); err != nil {
+						t.Fatal(err)
+					}
+
+					return
+				}
+
+				want, err := os.ReadFile(after)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got := string(doc.Bytes()); got != string(want) {
+					t.Errorf("%s: got\n%s\nwant\n%s", after, got, want)
+				}
+
+				// The edited content must parse again, as the next edit would.
+				if _, err := edit.Parse(doc.Bytes(), format); err != nil {
+					t.Errorf("parsing the edited content: %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestSetReadsBack(t *testing.T) {
+	t.Parallel()
+
+	inputs := map[edit.Format]string{
+		edit.YAML: "dev:\n  env:\n    A: plain\n",
+		edit.TOML: "[dev.env]\nA = \"plain\"\n",
+	}
+
+	values := []string{"tab\there", "escape\x1b[0m", "line\r\nbreak", "bell\a", " leading", "it's \"quoted\"", "#hash"}
+
+	for format, input := range inputs {
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := edit.Parse([]byte(input), format)
+			if err != nil {
+				t.Fatalf("parsing: %v", err)
+			}
+
+			for _, value := range values {
+				if err := doc.Set("dev", "A", value); err != nil {
+					t.Fatalf("setting %q: %v", value, err)
+				}
+
+				profiles, _, err := envprof.Unmarshal(doc.Bytes(), envprof.Type(format))
+				if err != nil {
+					t.Fatalf("unmarshaling:\n%s\n%v", doc.Bytes(), err)
+				}
+
+				dev := profiles["dev"]
+
+				env, err := dev.Env.Stringified()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got := env.Get("A"); got != value {
+					t.Errorf("set %q, read back %q from\n%s", value, got, doc.Bytes())
+				}
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		format edit.Format
+		input  string
+		op     operation
+		want   error
+	}{
+		{
+			name:   "yaml missing profile",
+			format: edit.YAML,
+			input:  "dev: {}\n",
+			op:     set("prod", "A", "1"),
+			want:   edit.ErrNotFound,
+		},
+		{
+			name:   "yaml missing key",
+			format: edit.YAML,
+			input:  "dev:\n  env:\n    A: 1\n",
+			op:     unset("dev", "B"),
+			want:   edit.ErrNotFound,
+		},
+		{
+			name:   "yaml flow mapping with entries",
+			format: edit.YAML,
+			input:  "dev:\n  env: {A: 1}\n",
+			op:     set("dev", "B", "2"),
+		},
+		{
+			name:   "yaml unset from flow mapping",
+			format: edit.YAML,
+			input:  "dev:\n  env: {A: 1, B: 2}\n",
+			op:     unset("dev", "A"),
+		},
+		{
+			name:   "yaml multi-line value",
+			format: edit.YAML,
+			input:  "dev:\n  env:\n    A: |\n      multi\n      line\n",
+			op:     set("dev", "A", "1"),
+			want:   edit.ErrUnsupported,
+		},
+		{
+			name:   "toml missing profile",
+			format: edit.TOML,
+			input:  "[dev.env]\nA = \"1\"\n",
+			op:     set("prod", "A", "1"),
+			want:   edit.ErrNotFound,
+		},
+		{
+			name:   "toml missing key",
+			format: edit.TOML,
+			input:  "[dev.env]\nA = \"1\"\n",
+			op:     unset("dev", "B"),
+			want:   edit.ErrNotFound,
+		},
+		{
+			name:   "toml inline env table",
+			format: edit.TOML,
+			input:  "[dev]\nenv = { A = \"1\" }\n",
+			op:     set("dev", "B", "2"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := edit.Parse([]byte(test.input), test.format)
+			if err != nil {
+				t.Fatalf("parsing: %v", err)
+			}
+
+			err = test.op(doc)
+
+			switch {
+			case err == nil:
+				t.Fatalf("editing succeeded, want an error\n%s", doc.Bytes())
+			case test.want != nil && !errors.Is(err, test.want):
+				t.Errorf("editing: %v, want %v", err, test.want)
+			}
+
+			if string(doc.Bytes()) != test.input {
+				t.Errorf("failed edit changed the content:\n%s", doc.Bytes())
+			}
+		})
+	}
+}
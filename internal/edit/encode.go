@@ -0,0 +1,335 @@
+package edit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// step is the indentation of each nesting level in YAML and JSON.
+const step = 2
+
+// encodeYAML encodes the tree as YAML, in block style except for flow style sequences and mappings,
+// separating the top-level keys with blank lines.
+func encodeYAML(root *node) []byte {
+	var buf bytes.Buffer
+
+	fields, _ := root.value.(mapping)
+
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		writeYAMLField(&buf, field, 0)
+	}
+
+	return buf.Bytes()
+}
+
+// writeYAMLField writes the key and value of the field at the indentation.
+func writeYAMLField(buf *bytes.Buffer, field field, indent int) {
+	writeComments(buf, field.value.comments, indent)
+
+	buf.WriteString(pad(indent) + yamlScalar(field.key) + ":")
+
+	switch value := field.value.value.(type) {
+	case mapping:
+		if field.value.flow || len(value) == 0 {
+			buf.WriteString(" " + yamlFlow(field.value) + lineComment(field.value) + "\n")
+
+			return
+		}
+
+		buf.WriteString(lineComment(field.value) + "\n")
+
+		for _, child := range value {
+			writeYAMLField(buf, child, indent+step)
+		}
+	case []*node:
+		if field.value.flow || len(value) == 0 {
+			buf.WriteString(" " + yamlFlow(field.value) + lineComment(field.value) + "\n")
+
+			return
+		}
+
+		buf.WriteString(lineComment(field.value) + "\n")
+
+		for _, item := range value {
+			writeYAMLItem(buf, item, indent+step)
+		}
+	default:
+		buf.WriteString(" " + yamlScalar(value) + lineComment(field.value) + "\n")
+	}
+}
+
+// writeYAMLItem writes a sequence item at the indentation.
+func writeYAMLItem(buf *bytes.Buffer, item *node, indent int) {
+	writeComments(buf, item.comments, indent)
+
+	fields, ok := item.value.(mapping)
+	if !ok || item.flow || len(fields) == 0 {
+		buf.WriteString(pad(indent) + "- " + yamlFlow(item) + lineComment(item) + "\n")
+
+		return
+	}
+
+	var nested bytes.Buffer
+
+	for _, field := range fields {
+		writeYAMLField(&nested, field, indent+step)
+	}
+
+	// The first line of the mapping starts right after the dash.
+	buf.WriteString(pad(indent) + "- " + strings.TrimPrefix(nested.String(), pad(indent+step)))
+}
+
+// yamlFlow formats the value in flow style.
+func yamlFlow(n *node) string {
+	switch value := n.value.(type) {
+	case mapping:
+		entries := make([]string, 0, len(value))
+
+		for _, field := range value {
+			entries = append(entries, yamlFlowScalar(field.key)+": "+yamlFlow(field.value))
+		}
+
+		return "{" + strings.Join(entries, ", ") + "}"
+	case []*node:
+		items := make([]string, 0, len(value))
+
+		for _, item := range value {
+			items = append(items, yamlFlow(item))
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return yamlFlowScalar(value)
+	}
+}
+
+// yamlScalar formats the scalar for block style, quoting strings where needed.
+func yamlScalar(value any) string {
+	text, ok := value.(string)
+	if !ok {
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return strconv.Quote(fmt.Sprint(value))
+		}
+
+		return strings.TrimSpace(string(data))
+	}
+
+	return quoted(text)
+}
+
+// yamlFlowScalar formats the scalar for flow style, also quoting strings with flow indicators.
+func yamlFlowScalar(value any) string {
+	if text, ok := value.(string); ok && strings.ContainsAny(text, ",[]{}") {
+		return strconv.Quote(text)
+	}
+
+	return yamlScalar(value)
+}
+
+// lineComment formats the inline comment of the node, if any.
+func lineComment(n *node) string {
+	if n.inline == "" {
+		return ""
+	}
+
+	return " #" + n.inline
+}
+
+// writeComments writes the comment lines at the indentation.
+func writeComments(buf *bytes.Buffer, comments []string, indent int) {
+	for _, comment := range comments {
+		buf.WriteString(pad(indent) + "#" + comment + "\n")
+	}
+}
+
+// encodeTOML encodes the tree as TOML, with a table for every mapping outside of arrays.
+// Tables without keys of their own are left out if they have nested tables, and null values are skipped.
+func encodeTOML(root *node) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := writeTOMLTable(&buf, nil, root, nil); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeTOMLTable writes the table at the key, its values first and its nested tables after,
+// with the comments carried over from left out parent tables.
+func writeTOMLTable(buf *bytes.Buffer, key []string, table *node, carried []string) error {
+	fields, _ := table.value.(mapping)
+
+	var values, tables mapping
+
+	for _, field := range fields {
+		switch {
+		case field.value.value == nil:
+		case isMapping(field.value):
+			tables = append(tables, field)
+		default:
+			values = append(values, field)
+		}
+	}
+
+	comments := slices.Concat(carried, table.comments)
+
+	if len(key) > 0 && (len(values) > 0 || len(tables) == 0) {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+
+		writeComments(buf, comments, 0)
+		buf.WriteString("[" + formatKey(key...) + "]" + lineComment(table) + "\n")
+
+		comments = nil
+	} else if table.inline != "" {
+		comments = append(comments, table.inline)
+	}
+
+	for _, field := range values {
+		value, err := tomlValue(field.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", formatKey(append(key, field.key)...), err)
+		}
+
+		writeComments(buf, field.value.comments, 0)
+		buf.WriteString(formatKey(field.key) + " = " + value + lineComment(field.value) + "\n")
+	}
+
+	for i, field := range tables {
+		if i > 0 {
+			comments = nil
+		}
+
+		if err := writeTOMLTable(buf, append(key[:len(key):len(key)], field.key), field.value, comments); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tomlValue formats the value inline, with arrays on a single line and mappings as inline tables.
+func tomlValue(n *node) (string, error) {
+	switch value := n.value.(type) {
+	case nil:
+		return "", errNull
+	case mapping:
+		entries := make([]string, 0, len(value))
+
+		for _, field := range value {
+			if field.value.value == nil {
+				continue
+			}
+
+			formatted, err := tomlValue(field.value)
+			if err != nil {
+				return "", err
+			}
+
+			entries = append(entries, formatKey(field.key)+" = "+formatted)
+		}
+
+		if len(entries) == 0 {
+			return "{}", nil
+		}
+
+		return "{ " + strings.Join(entries, ", ") + " }", nil
+	case []*node:
+		items := make([]string, 0, len(value))
+
+		for _, item := range value {
+			formatted, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+
+			items = append(items, formatted)
+		}
+
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	default:
+		return tomlScalar(value)
+	}
+}
+
+// encodeJSON encodes the tree as indented JSON, keeping the order of keys.
+func encodeJSON(root *node) []byte {
+	var buf bytes.Buffer
+
+	writeJSON(&buf, root, 0)
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
+
+// writeJSON writes the value at the indentation.
+func writeJSON(buf *bytes.Buffer, n *node, indent int) {
+	switch value := n.value.(type) {
+	case mapping:
+		if len(value) == 0 {
+			buf.WriteString("{}")
+
+			return
+		}
+
+		buf.WriteString("{\n")
+
+		for i, field := range value {
+			key, _ := json.Marshal(field.key)
+
+			buf.WriteString(pad(indent+step) + string(key) + ": ")
+			writeJSON(buf, field.value, indent+step)
+
+			if i < len(value)-1 {
+				buf.WriteString(",")
+			}
+
+			buf.WriteString("\n")
+		}
+
+		buf.WriteString(pad(indent) + "}")
+	case []*node:
+		if len(value) == 0 {
+			buf.WriteString("[]")
+
+			return
+		}
+
+		buf.WriteString("[\n")
+
+		for i, item := range value {
+			buf.WriteString(pad(indent + step))
+			writeJSON(buf, item, indent+step)
+
+			if i < len(value)-1 {
+				buf.WriteString(",")
+			}
+
+			buf.WriteString("\n")
+		}
+
+		buf.WriteString(pad(indent) + "]")
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			data, _ = json.Marshal(fmt.Sprint(value))
+		}
+
+		buf.Write(data)
+	}
+}
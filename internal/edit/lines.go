@@ -0,0 +1,102 @@
+package edit
+
+import (
+	"slices"
+	"strings"
+)
+
+// lines are the lines of a file, each including its line ending.
+type lines []string
+
+// split splits the content into lines.
+func split(data []byte) lines {
+	return strings.SplitAfter(string(data), "\n")
+}
+
+// String joins the lines.
+func (l lines) String() string {
+	return strings.Join(l, "")
+}
+
+// newline returns the line ending of the file, from its first line, defaulting to "\n".
+func (l lines) newline() string {
+	if len(l) > 0 && strings.HasSuffix(l[0], "\r\n") {
+		return "\r\n"
+	}
+
+	return "\n"
+}
+
+// insert inserts the new lines after the line at index after, or at the start if after is -1.
+// The new lines end in "\n", which is converted to the line ending of the file.
+func (l *lines) insert(after int, added ...string) {
+	newline := l.newline()
+
+	if after >= 0 && !strings.HasSuffix((*l)[after], "\n") {
+		(*l)[after] += newline
+	}
+
+	added = slices.Clone(added)
+
+	for i, line := range added {
+		if !strings.HasSuffix(line, "\r\n") {
+			if trimmed, ok := strings.CutSuffix(line, "\n"); ok {
+				added[i] = trimmed + newline
+			}
+		}
+	}
+
+	*l = slices.Insert(*l, after+1, added...)
+}
+
+// append appends the new lines as a separate block at the end, after a blank line.
+func (l *lines) append(added ...string) {
+	last := l.last()
+
+	if last >= 0 && strings.TrimSpace((*l)[last]) != "" {
+		added = append([]string{"\n"}, added...)
+	}
+
+	l.insert(last, added...)
+}
+
+// last returns the index of the last non-empty line, or -1 if there is none.
+func (l lines) last() int {
+	for i := len(l) - 1; i >= 0; i-- {
+		if strings.TrimSpace(l[i]) != "" {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// remove removes the lines from index from to index to, inclusive, and a blank line left doubled by the removal.
+// With comments, the comment lines directly above are removed as well.
+func (l *lines) remove(from, to int, comments bool) {
+	if comments {
+		for from > 0 && strings.HasPrefix(strings.TrimSpace((*l)[from-1]), "#") {
+			from--
+		}
+	}
+
+	*l = slices.Delete(*l, from, to+1)
+
+	empty := func(i int) bool { return i >= 0 && i < len(*l) && strings.TrimSpace((*l)[i]) == "" && (*l)[i] != "" }
+
+	if empty(from) && (from == 0 || empty(from-1)) {
+		*l = slices.Delete(*l, from, from+1)
+	}
+}
+
+// indentation returns the number of leading spaces of the line.
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// blank reports whether the line is empty or a comment.
+func blank(line string) bool {
+	trimmed := strings.TrimSpace(line)
+
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
@@ -0,0 +1,213 @@
+package edit
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/idelchi/envprof/internal/envprof"
+	"github.com/idelchi/envprof/internal/profile"
+)
+
+// Order is the order of the profiles in a normalized file.
+type Order string
+
+const (
+	// ByName sorts the profiles by name.
+	ByName Order = "name"
+	// ByDependency puts extended profiles before the profiles extending them, and sorts by name otherwise.
+	ByDependency Order = "dependency"
+)
+
+// EnvStyle is the style of env in a normalized file.
+type EnvStyle string
+
+const (
+	// KeepEnv keeps the style of each env.
+	KeepEnv EnvStyle = "keep"
+	// MapEnv writes env as mappings of keys to values.
+	MapEnv EnvStyle = "map"
+	// SequenceEnv writes env as sequences of KEY=VALUE strings, only supported in YAML.
+	SequenceEnv EnvStyle = "sequence"
+)
+
+// Style are the preferences of a normalized file.
+type Style struct {
+	// Order is the order of the profiles.
+	Order Order
+	// Env is the style of env.
+	Env EnvStyle
+}
+
+// Normalize rewrites the content of a profile file in canonical form, preserving comments:
+// the settings first and the profiles in order, env sorted by key and written in the preferred style,
+// extends of profiles with an explicit "profile:" prefix, and values quoted consistently.
+func Normalize(data []byte, format Format, style Style) ([]byte, error) {
+	var (
+		root *node
+		err  error
+	)
+
+	switch format {
+	case YAML:
+		root, err = decodeYAML(data)
+	case TOML:
+		root, err = decodeTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported file format: %q", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if format == TOML && style.Env == SequenceEnv {
+		return nil, fmt.Errorf("env sequences are not supported in %s", format)
+	}
+
+	profiles, _ := root.value.(mapping)
+
+	for _, field := range profiles {
+		if field.key == envprof.Reserved {
+			continue
+		}
+
+		if err := normalizeProfile(field, style.Env); err != nil {
+			return nil, err
+		}
+	}
+
+	root.value = order(profiles, style.Order)
+
+	if format == TOML {
+		if err := envMappings(root); err != nil {
+			return nil, err
+		}
+
+		return encodeTOML(root)
+	}
+
+	return encodeYAML(root), nil
+}
+
+// normalizeProfile prefixes the extends of the profile and sorts its env, converting it to the style.
+func normalizeProfile(prof field, style EnvStyle) error {
+	fields, _ := prof.value.value.(mapping)
+
+	if extends := fields.get("extends"); extends != nil {
+		items, _ := extends.value.([]*node)
+
+		for _, item := range items {
+			if entry, ok := item.value.(string); ok && !strings.Contains(entry, ":") {
+				item.value = "profile:" + entry
+			}
+		}
+	}
+
+	env := fields.get("env")
+	if env == nil {
+		return nil
+	}
+
+	switch value := env.value.(type) {
+	case mapping:
+		slices.SortStableFunc(value, func(a, b field) int { return cmp.Compare(a.key, b.key) })
+
+		if style != SequenceEnv {
+			return nil
+		}
+
+		items := make([]*node, 0, len(value))
+
+		for _, field := range value {
+			text, err := profile.Stringify(plain(field.value))
+			if err != nil {
+				return fmt.Errorf("profile %q: env %q: %w", prof.key, field.key, err)
+			}
+
+			items = append(items, &node{
+				value:    field.key + "=" + text,
+				comments: field.value.comments,
+				inline:   field.value.inline,
+			})
+		}
+
+		env.value, env.flow = items, false
+	case []*node:
+		key := func(item *node) string {
+			text, _ := item.value.(string)
+			key, _, _ := strings.Cut(text, "=")
+
+			return key
+		}
+
+		slices.SortStableFunc(value, func(a, b *node) int { return cmp.Compare(key(a), key(b)) })
+
+		if style != MapEnv {
+			return nil
+		}
+
+		return envMappings(&node{value: mapping{prof}})
+	}
+
+	return nil
+}
+
+// order returns the fields with the settings first, followed by the profiles in order.
+func order(fields mapping, order Order) mapping {
+	sorted := slices.Clone(fields)
+
+	slices.SortStableFunc(sorted, func(a, b field) int {
+		switch {
+		case a.key == envprof.Reserved:
+			return -1
+		case b.key == envprof.Reserved:
+			return 1
+		default:
+			return cmp.Compare(a.key, b.key)
+		}
+	})
+
+	if order != ByDependency {
+		return sorted
+	}
+
+	var (
+		ordered mapping
+		visited = map[string]bool{}
+		visit   func(field field)
+	)
+
+	visit = func(current field) {
+		if visited[current.key] {
+			return
+		}
+
+		visited[current.key] = true
+
+		profile, _ := current.value.value.(mapping)
+
+		if extends := profile.get("extends"); extends != nil {
+			items, _ := extends.value.([]*node)
+
+			for _, item := range items {
+				entry, _ := item.value.(string)
+
+				if name, ok := strings.CutPrefix(entry, "profile:"); ok {
+					if extended := sorted.get(name); extended != nil {
+						visit(field{key: name, value: extended})
+					}
+				}
+			}
+		}
+
+		ordered = append(ordered, current)
+	}
+
+	for _, field := range sorted {
+		visit(field)
+	}
+
+	return ordered
+}
@@ -0,0 +1,104 @@
+package edit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/idelchi/envprof/internal/edit"
+)
+
+// TestNormalize normalizes the files in testdata/normalize/<name>.<format> in every style,
+// comparing with <name>.<order>-<env>.<format>.
+// Every result must be stable under normalizing again and load as the same profiles and settings as the source.
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	sources, err := filepath.Glob(filepath.Join("testdata", "normalize", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range sources {
+		name := filepath.Base(source)
+		if strings.Count(name, ".") != 1 {
+			continue
+		}
+
+		format := edit.Format(strings.TrimPrefix(filepath.Ext(name), "."))
+		stem := strings.TrimSuffix(source, filepath.Ext(source))
+
+		for _, order := range []edit.Order{edit.ByName, edit.ByDependency} {
+			for _, env := range []edit.EnvStyle{edit.KeepEnv, edit.MapEnv, edit.SequenceEnv} {
+				if format == edit.TOML && env == edit.SequenceEnv {
+					continue
+				}
+
+				style := edit.Style{Order: order, Env: env}
+
+				t.Run(name+"/"+string(order)+"-"+string(env), func(t *testing.T) {
+					t.Parallel()
+
+					data, err := os.ReadFile(source)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					normalized, err := edit.Normalize(data, format, style)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					golden(t, stem+"."+string(order)+"-"+string(env)+"."+string(format), normalized)
+					same(t, data, format, normalized, format)
+
+					again, err := edit.Normalize(normalized, format, style)
+					if err != nil {
+						t.Fatalf("normalizing again: %v", err)
+					}
+
+					if string(again) != string(normalized) {
+						t.Errorf("normalizing again changed the content: got\n%s\nwant\n%s", again, normalized)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		format edit.Format
+		style  edit.Style
+	}{
+		{
+			name:   "env sequences in TOML",
+			input:  "[dev.env]\nA = 1\n",
+			format: edit.TOML,
+			style:  edit.Style{Env: edit.SequenceEnv},
+		},
+		{
+			name:   "invalid env sequence entry",
+			input:  "dev:\n  env: [A]\n",
+			format: edit.YAML,
+			style:  edit.Style{Env: edit.MapEnv},
+		},
+		{name: "invalid YAML", input: "dev: [\n", format: edit.YAML},
+		{name: "unsupported format", input: "{}", format: edit.JSON},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if normalized, err := edit.Normalize([]byte(test.input), test.format, test.style); err == nil {
+				t.Errorf("normalizing succeeded, want an error\n%s", normalized)
+			}
+		})
+	}
+}
@@ -0,0 +1,735 @@
+package edit
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlDocument is a TOML profile file, edited line by line.
+type tomlDocument struct {
+	lines lines
+}
+
+// parseTOML checks that the content is valid TOML and splits it into lines.
+func parseTOML(data []byte) (*tomlDocument, error) {
+	var content map[string]any
+
+	if _, err := toml.Decode(string(data), &content); err != nil {
+		return nil, err
+	}
+
+	return &tomlDocument{lines: split(data)}, nil
+}
+
+// Bytes returns the edited content.
+func (d *tomlDocument) Bytes() []byte {
+	return []byte(d.lines.String())
+}
+
+// Value returns the value of the env variable of the profile, which must be a scalar.
+func (d *tomlDocument) Value(profile, key string) (string, error) {
+	assignment, err := d.find(profile, key)
+	if err != nil {
+		return "", err
+	}
+
+	raw := d.lines[assignment.line][assignment.start:assignment.end]
+
+	if !assignment.scalar {
+		return "", fmt.Errorf("profile %q: env %q: %w", profile, key, ErrUnsupported)
+	}
+
+	var decoded map[string]any
+
+	if _, err := toml.Decode("value = "+raw, &decoded); err != nil {
+		return "", fmt.Errorf("profile %q: env %q: %w", profile, key, err)
+	}
+
+	if value, ok := decoded["value"].(string); ok {
+		return value, nil
+	}
+
+	return raw, nil
+}
+
+// Replace replaces the value of an existing env variable of the profile with a string.
+func (d *tomlDocument) Replace(profile, key, value string) error {
+	assignment, err := d.find(profile, key)
+	if err != nil {
+		return err
+	}
+
+	if !assignment.scalar {
+		return fmt.Errorf("profile %q: env %q: %w", profile, key, ErrUnsupported)
+	}
+
+	encoded, err := tomlScalar(value)
+	if err != nil {
+		return err
+	}
+
+	line := d.lines[assignment.line]
+
+	d.lines[assignment.line] = line[:assignment.start] + encoded + line[assignment.end:]
+
+	return nil
+}
+
+// Set sets the env variable of the profile to a string, adding it after the existing ones if needed.
+// New variables go into the [profile.env] table, after "env.KEY" assignments of the [profile] table,
+// or into a new [profile.env] table.
+func (d *tomlDocument) Set(profile, key, value string) error {
+	if _, err := d.find(profile, key); err == nil {
+		return d.Replace(profile, key, value)
+	}
+
+	encoded, err := tomlScalar(value)
+	if err != nil {
+		return err
+	}
+
+	scan := d.scan()
+	assignment := formatKey(key) + " = " + encoded + "\n"
+
+	if table := scan.table(profile, "env"); table != nil {
+		d.lines.insert(table.end, assignment)
+
+		return nil
+	}
+
+	if table := scan.table(profile); table != nil {
+		last := -1
+
+		for _, existing := range scan.assignments {
+			if existing.table != table.index || existing.local[0] != "env" {
+				continue
+			}
+
+			if len(existing.local) == 1 {
+				return fmt.Errorf("profile %q: env: inline tables cannot be edited, use a [%s.env] table",
+					profile, profile)
+			}
+
+			last = existing.last
+		}
+
+		if last >= 0 {
+			d.lines.insert(last, "env."+assignment)
+		} else {
+			d.lines.insert(table.end, "\n", "["+formatKey(profile, "env")+"]\n", assignment)
+		}
+
+		return nil
+	}
+
+	if !scan.defines(profile) {
+		return fmt.Errorf("profile %q: %w", profile, ErrNotFound)
+	}
+
+	d.lines.append("["+formatKey(profile, "env")+"]\n", assignment)
+
+	return nil
+}
+
+// Unset removes the env variable from the profile, along with the comments directly above it.
+func (d *tomlDocument) Unset(profile, key string) error {
+	assignment, err := d.find(profile, key)
+	if err != nil {
+		return err
+	}
+
+	d.lines.remove(assignment.line, assignment.last, true)
+
+	return nil
+}
+
+// Extends returns the extends entries of the profile.
+func (d *tomlDocument) Extends(profile string) ([]string, error) {
+	scan := d.scan()
+
+	assignment, ok := scan.assignment(profile, "extends")
+	if !ok {
+		if !scan.defines(profile) {
+			return nil, fmt.Errorf("profile %q: %w", profile, ErrNotFound)
+		}
+
+		return nil, nil
+	}
+
+	text := d.lines[assignment.line][assignment.start:] +
+		strings.Join(d.lines[assignment.line+1:assignment.last+1], "")
+
+	var decoded struct {
+		Value []string `toml:"value"`
+	}
+
+	if _, err := toml.Decode("value = "+text, &decoded); err != nil {
+		return nil, fmt.Errorf("profile %q: extends: %w", profile, err)
+	}
+
+	return decoded.Value, nil
+}
+
+// SetExtends replaces the extends entries of the profile, removing them if empty.
+// New entries are added as the first assignment of the [profile] table, which is created if needed.
+func (d *tomlDocument) SetExtends(profile string, extends []string) error {
+	array, err := tomlArray(extends)
+	if err != nil {
+		return err
+	}
+
+	scan := d.scan()
+
+	if assignment, ok := scan.assignment(profile, "extends"); ok {
+		if len(extends) == 0 {
+			d.lines.remove(assignment.line, assignment.last, false)
+
+			return nil
+		}
+
+		line := d.lines[assignment.line]
+
+		rest := "\n"
+		if assignment.last == assignment.line {
+			rest = line[assignment.end:]
+		} else {
+			d.lines = slices.Delete(d.lines, assignment.line+1, assignment.last+1)
+		}
+
+		d.lines[assignment.line] = line[:assignment.start] + array + rest
+
+		return nil
+	}
+
+	if len(extends) == 0 {
+		return nil
+	}
+
+	if table := scan.table(profile); table != nil {
+		d.lines.insert(table.header, "extends = "+array+"\n")
+
+		return nil
+	}
+
+	for _, table := range scan.tables {
+		if table.key[0] == profile {
+			d.lines.insert(table.header-1, "["+formatKey(profile)+"]\n", "extends = "+array+"\n", "\n")
+
+			return nil
+		}
+	}
+
+	if scan.defines(profile) {
+		return scan.tablesOnly(profile)
+	}
+
+	return fmt.Errorf("profile %q: %w", profile, ErrNotFound)
+}
+
+// AddProfile adds an empty profile at the end.
+func (d *tomlDocument) AddProfile(name string) error {
+	d.lines.append("[" + formatKey(name) + "]\n")
+
+	return nil
+}
+
+// CopyProfile adds a copy of the tables of the profile under a new name at the end.
+func (d *tomlDocument) CopyProfile(from, to string) error {
+	scan := d.scan()
+
+	if err := scan.tablesOnly(from); err != nil {
+		return err
+	}
+
+	var copied []string
+
+	for _, table := range scan.tables {
+		if table.key[0] != from {
+			continue
+		}
+
+		header, err := renameHeader(d.lines[table.header], to)
+		if err != nil {
+			return err
+		}
+
+		if len(copied) > 0 {
+			copied = append(copied, "\n")
+		}
+
+		copied = append(copied, header)
+
+		for i := table.header + 1; i <= table.end; i++ {
+			// The copy must not become a second default profile.
+			if len(table.key) == 1 && slices.ContainsFunc(scan.assignments, func(a tomlAssignment) bool {
+				return a.table == table.index && slices.Equal(a.local, []string{"default"}) && a.line <= i && i <= a.last
+			}) {
+				continue
+			}
+
+			copied = append(copied, d.lines[i])
+		}
+	}
+
+	if len(copied) == 0 {
+		return fmt.Errorf("profile %q: %w", from, ErrNotFound)
+	}
+
+	if last := len(copied) - 1; !strings.HasSuffix(copied[last], "\n") {
+		copied[last] += "\n"
+	}
+
+	d.lines.append(copied...)
+
+	return nil
+}
+
+// RenameProfile renames the tables of the profile, without updating references to it.
+func (d *tomlDocument) RenameProfile(from, to string) error {
+	scan := d.scan()
+
+	if err := scan.tablesOnly(from); err != nil {
+		return err
+	}
+
+	renamed := false
+
+	for _, table := range scan.tables {
+		if table.key[0] != from {
+			continue
+		}
+
+		header, err := renameHeader(d.lines[table.header], to)
+		if err != nil {
+			return err
+		}
+
+		d.lines[table.header] = header
+		renamed = true
+	}
+
+	if !renamed {
+		return fmt.Errorf("profile %q: %w", from, ErrNotFound)
+	}
+
+	return nil
+}
+
+// DeleteProfile removes the tables of the profile, along with the comments directly above them.
+func (d *tomlDocument) DeleteProfile(name string) error {
+	scan := d.scan()
+
+	if err := scan.tablesOnly(name); err != nil {
+		return err
+	}
+
+	deleted := false
+
+	for _, table := range slices.Backward(scan.tables) {
+		if table.key[0] == name {
+			d.lines.remove(table.header, table.end, true)
+
+			deleted = true
+		}
+	}
+
+	if !deleted {
+		return fmt.Errorf("profile %q: %w", name, ErrNotFound)
+	}
+
+	return nil
+}
+
+// tomlTable is a table started by a "[key]" header.
+type tomlTable struct {
+	// index is the index of the table within the scan.
+	index int
+	// key is the key of the table.
+	key []string
+	// header is the index of the header line, end the index of the last line that is not blank or a comment.
+	header, end int
+}
+
+// tomlAssignment is a "key = value" line.
+type tomlAssignment struct {
+	// line is the index of the line, last the index of the last line of a multi-line value.
+	line, last int
+	// table is the index of the table the assignment is in, or -1 at the top level.
+	table int
+	// local is the key as written, key the full key, including the table.
+	local, key []string
+	// start and end delimit the value within the line.
+	start, end int
+	// scalar reports whether the value is a single-line scalar.
+	scalar bool
+}
+
+// tomlScan are the tables and assignments of a document.
+type tomlScan struct {
+	tables      []tomlTable
+	assignments []tomlAssignment
+}
+
+// table returns the table with the key, or nil if there is none.
+func (s tomlScan) table(key ...string) *tomlTable {
+	for i := range s.tables {
+		if slices.Equal(s.tables[i].key, key) {
+			return &s.tables[i]
+		}
+	}
+
+	return nil
+}
+
+// assignment returns the assignment with the full key.
+func (s tomlScan) assignment(key ...string) (tomlAssignment, bool) {
+	for _, assignment := range s.assignments {
+		if slices.Equal(assignment.key, key) {
+			return assignment, true
+		}
+	}
+
+	return tomlAssignment{}, false
+}
+
+// defines reports whether any table or assignment belongs to the profile.
+func (s tomlScan) defines(profile string) bool {
+	return slices.ContainsFunc(s.tables, func(t tomlTable) bool { return t.key[0] == profile }) ||
+		slices.ContainsFunc(s.assignments, func(a tomlAssignment) bool { return a.key[0] == profile })
+}
+
+// tablesOnly checks that the profile is only defined through tables, not through dotted keys at the top level.
+func (s tomlScan) tablesOnly(profile string) error {
+	for _, assignment := range s.assignments {
+		if assignment.table < 0 && assignment.key[0] == profile {
+			return fmt.Errorf("profile %q: dotted keys at the top level cannot be edited, use a [%s] table",
+				profile, profile)
+		}
+	}
+
+	return nil
+}
+
+// find locates the assignment of the env variable of the profile,
+// in a [profile.env] table, as "env.KEY" in a [profile] table, or as a dotted key at the top level.
+func (d *tomlDocument) find(profile, key string) (tomlAssignment, error) {
+	if assignment, ok := d.scan().assignment(profile, "env", key); ok {
+		return assignment, nil
+	}
+
+	return tomlAssignment{}, notFound(profile, key)
+}
+
+// scan returns the tables and assignments of the document, skipping over multi-line values.
+func (d *tomlDocument) scan() tomlScan {
+	var (
+		scan    tomlScan
+		current = -1
+		closing string // delimiter closing a multi-line value, if inside one
+		depth   int    // nesting depth of a multi-line array or inline table, if inside one
+	)
+
+	// extend makes the line part of the last assignment, and of the current table.
+	extend := func(i int) {
+		scan.assignments[len(scan.assignments)-1].last = i
+
+		if current >= 0 {
+			scan.tables[current].end = i
+		}
+	}
+
+	for i, line := range d.lines {
+		switch {
+		case closing != "":
+			extend(i)
+
+			if strings.Contains(line, closing) {
+				closing = ""
+			}
+
+			continue
+		case depth > 0:
+			extend(i)
+
+			depth += nesting(line)
+
+			continue
+		case blank(line):
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "[") {
+			if header, ok := parseHeader(trimmed); ok {
+				current = len(scan.tables)
+				scan.tables = append(scan.tables, tomlTable{index: current, key: header, header: i, end: i})
+			}
+
+			continue
+		}
+
+		if current >= 0 {
+			scan.tables[current].end = i
+		}
+
+		key, rest, ok := parseKey(line)
+		if !ok {
+			continue
+		}
+
+		start := len(line) - len(strings.TrimLeft(rest, " \t"))
+		end, scalar := valueEnd(line, start)
+
+		switch value := line[start:]; {
+		case strings.HasPrefix(value, `"""`) && !strings.Contains(value[3:], `"""`):
+			closing = `"""`
+		case strings.HasPrefix(value, "'''") && !strings.Contains(value[3:], "'''"):
+			closing = "'''"
+		case strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{"):
+			depth = nesting(value)
+		}
+
+		var table []string
+		if current >= 0 {
+			table = scan.tables[current].key
+		}
+
+		scan.assignments = append(scan.assignments, tomlAssignment{
+			line:   i,
+			last:   i,
+			table:  current,
+			local:  key,
+			key:    append(slices.Clone(table), key...),
+			start:  start,
+			end:    end,
+			scalar: scalar,
+		})
+	}
+
+	return scan
+}
+
+// renameHeader returns the table header line with the first part of its key replaced by the name.
+func renameHeader(line, name string) (string, error) {
+	trimmed := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(trimmed)]
+
+	brackets := "["
+	if strings.HasPrefix(trimmed, "[[") {
+		brackets = "[["
+	}
+
+	key, rest, ok := parseKeyUntil(trimmed[len(brackets):], ']')
+	if !ok {
+		return "", fmt.Errorf("table header %q: %w", strings.TrimSpace(line), ErrUnsupported)
+	}
+
+	key[0] = name
+
+	return indent + brackets + formatKey(key...) + rest, nil
+}
+
+// formatKey formats a dotted key, quoting the parts that cannot be bare keys.
+func formatKey(parts ...string) string {
+	formatted := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		if part == "" || strings.IndexFunc(part, func(r rune) bool { return !bareKey(r) }) >= 0 {
+			part = strconv.Quote(part)
+		}
+
+		formatted = append(formatted, part)
+	}
+
+	return strings.Join(formatted, ".")
+}
+
+// bareKey reports whether the character is allowed in bare keys.
+func bareKey(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+// tomlArray encodes the strings as a single-line TOML array.
+func tomlArray(values []string) (string, error) {
+	items := make([]string, 0, len(values))
+
+	for _, value := range values {
+		encoded, err := tomlScalar(value)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, encoded)
+	}
+
+	return "[" + strings.Join(items, ", ") + "]", nil
+}
+
+// parseHeader parses a table header such as "[a.b]" or "[[a]]", returning the table key.
+func parseHeader(line string) ([]string, bool) {
+	inner := strings.TrimPrefix(strings.TrimPrefix(line, "["), "[")
+
+	key, rest, ok := parseKeyUntil(inner, ']')
+	if !ok || !strings.HasPrefix(rest, "]") {
+		return nil, false
+	}
+
+	return key, true
+}
+
+// parseKey parses the key of a "key = value" line, returning the key and the rest of the line after the '='.
+func parseKey(line string) ([]string, string, bool) {
+	key, rest, ok := parseKeyUntil(strings.TrimLeft(line, " \t"), '=')
+	if !ok || !strings.HasPrefix(rest, "=") {
+		return nil, "", false
+	}
+
+	return key, line[len(line)-len(rest)+1:], true
+}
+
+// parseKeyUntil parses a dotted key of bare and quoted parts, stopping at the terminator.
+// Returns the key parts and the remainder, starting with the terminator.
+func parseKeyUntil(s string, terminator byte) ([]string, string, bool) {
+	var key []string
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+
+		if s == "" {
+			return nil, "", false
+		}
+
+		var part string
+
+		switch s[0] {
+		case '"':
+			end := closingQuote(s)
+			if end < 0 {
+				return nil, "", false
+			}
+
+			unquoted, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, "", false
+			}
+
+			part, s = unquoted, s[end+1:]
+		case '\'':
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, "", false
+			}
+
+			part, s = s[1:end+1], s[end+2:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool { return !bareKey(r) })
+			if end <= 0 {
+				return nil, "", false
+			}
+
+			part, s = s[:end], s[end:]
+		}
+
+		key = append(key, part)
+
+		s = strings.TrimLeft(s, " \t")
+
+		switch {
+		case strings.HasPrefix(s, "."):
+			s = s[1:]
+		case s != "" && s[0] == terminator:
+			return key, s, true
+		default:
+			return nil, "", false
+		}
+	}
+}
+
+// closingQuote returns the index of the quote closing the basic string at the start of s, or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// valueEnd returns the end of the value starting at start, excluding a trailing comment,
+// and whether it is a single-line scalar.
+func valueEnd(line string, start int) (int, bool) {
+	value := line[start:]
+
+	switch {
+	case strings.HasPrefix(value, `"""`), strings.HasPrefix(value, "'''"),
+		strings.HasPrefix(value, "["), strings.HasPrefix(value, "{"):
+		return len(strings.TrimRight(line, " \t\r\n")), false
+	case strings.HasPrefix(value, `"`):
+		if end := closingQuote(value); end >= 0 {
+			return start + end + 1, true
+		}
+	case strings.HasPrefix(value, "'"):
+		if end := strings.IndexByte(value[1:], '\''); end >= 0 {
+			return start + end + 2, true
+		}
+	}
+
+	if comment := strings.IndexByte(value, '#'); comment >= 0 {
+		value = value[:comment]
+	}
+
+	return start + len(strings.TrimRight(value, " \t\r\n")), true
+}
+
+// nesting returns the change in nesting depth of arrays and inline tables over the line, ignoring strings and comments.
+func nesting(line string) int {
+	depth := 0
+
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case '#':
+			return depth
+		case '"':
+			end := closingQuote(line[i:])
+			if end < 0 {
+				return depth
+			}
+
+			i += end
+		case '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return depth
+			}
+
+			i += end + 1
+		}
+	}
+
+	return depth
+}
+
+// tomlScalar encodes the scalar as a TOML value, such as a basic string.
+func tomlScalar(value any) (string, error) {
+	data, err := toml.Marshal(map[string]any{"value": value})
+	if err != nil {
+		return "", err
+	}
+
+	_, encoded, _ := strings.Cut(strings.TrimSpace(string(data)), "= ")
+
+	return encoded, nil
+}
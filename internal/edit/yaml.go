@@ -0,0 +1,691 @@
+package edit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// yamlDocument is a YAML profile file. Edits are located through the AST and applied to the source lines,
+// leaving everything else as it was.
+type yamlDocument struct {
+	lines lines
+	file  *ast.File
+}
+
+// parseYAML parses YAML content, keeping comments.
+func parseYAML(data []byte) (*yamlDocument, error) {
+	d := &yamlDocument{lines: split(data)}
+
+	return d, d.parse()
+}
+
+// parse parses the current lines into the AST.
+// Line endings are normalized for parsing, as the parser miscounts lines ending in "\r\n" after comments.
+func (d *yamlDocument) parse() error {
+	file, err := parser.ParseBytes(bytes.ReplaceAll(d.Bytes(), []byte("\r\n"), []byte("\n")), parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	d.file = file
+
+	return nil
+}
+
+// Bytes returns the edited content.
+func (d *yamlDocument) Bytes() []byte {
+	return []byte(d.lines.String())
+}
+
+// Value returns the value of the env variable of the profile, which must be a scalar.
+func (d *yamlDocument) Value(profile, key string) (string, error) {
+	entry, err := d.entry(profile, key)
+	if err != nil {
+		return "", err
+	}
+
+	if entry.item != nil {
+		_, value, _ := strings.Cut(scalar(entry.item), "=")
+
+		return value, nil
+	}
+
+	switch entry.value.Value.(type) {
+	case *ast.MappingNode, *ast.MappingValueNode, *ast.SequenceNode, *ast.TagNode, *ast.AnchorNode, *ast.AliasNode:
+		return "", fmt.Errorf("profile %q: env %q: %w", profile, key, ErrUnsupported)
+	}
+
+	return scalar(entry.value.Value), nil
+}
+
+// Replace replaces the value of an existing env variable of the profile with a string.
+func (d *yamlDocument) Replace(profile, key, value string) error {
+	entry, err := d.entry(profile, key)
+	if err != nil {
+		return err
+	}
+
+	node, replacement := entry.item, key+"="+value
+	if node == nil {
+		node, replacement = entry.value.Value, value
+	}
+
+	encoded := yamlScalar(replacement)
+
+	if env, _ := d.env(profile); flow(env) {
+		encoded = yamlFlowScalar(replacement)
+	}
+
+	if err := d.splice(node, encoded); err != nil {
+		return fmt.Errorf("profile %q: env %q: %w", profile, key, err)
+	}
+
+	return d.parse()
+}
+
+// splice replaces the text of a single-line scalar node with the replacement.
+func (d *yamlDocument) splice(node ast.Node, replacement string) error {
+	switch node.(type) {
+	case *ast.StringNode, *ast.IntegerNode, *ast.FloatNode, *ast.BoolNode, *ast.NullNode, *ast.InfinityNode,
+		*ast.NanNode:
+	default:
+		return ErrUnsupported
+	}
+
+	position := node.GetToken().Position
+
+	line := []rune(d.lines[position.Line-1])
+	start := position.Column - 1
+
+	end, ok := scalarEnd(line, start)
+
+	// Plain scalars end with their token, which is followed by a comma or bracket within flow style.
+	// Plain scalars continued on the next lines do not match their token.
+	if plain := !strings.ContainsRune(`"'`, line[start]); plain {
+		token := []rune(node.GetToken().Value)
+		end = start + len(token)
+
+		ok = end <= len(line) && string(line[start:end]) == string(token) &&
+			(end == len(line) || strings.ContainsRune(" \t\r\n,]}", line[end]))
+	}
+
+	if !ok {
+		return ErrUnsupported
+	}
+
+	d.lines[position.Line-1] = string(line[:start]) + replacement + string(line[end:])
+
+	return nil
+}
+
+// scalarEnd returns the end of the scalar starting at start, excluding a trailing comment,
+// and whether the scalar ends on the line.
+func scalarEnd(line []rune, start int) (int, bool) {
+	switch line[start] {
+	case '"':
+		for i := start + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, true
+			}
+		}
+
+		return 0, false
+	case '\'':
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\'' {
+				if i+1 < len(line) && line[i+1] == '\'' {
+					i++
+
+					continue
+				}
+
+				return i + 1, true
+			}
+		}
+
+		return 0, false
+	}
+
+	end := len(line)
+
+	for i := start; i < len(line); i++ {
+		if line[i] == '#' && i > start && (line[i-1] == ' ' || line[i-1] == '\t') {
+			end = i
+
+			break
+		}
+	}
+
+	return start + len(strings.TrimRight(string(line[start:end]), " \t\r\n")), true
+}
+
+// Set sets the env variable of the profile to a string, adding it after the existing ones if needed.
+func (d *yamlDocument) Set(profile, key, value string) error {
+	if _, err := d.entry(profile, key); !errors.Is(err, ErrNotFound) {
+		if err != nil {
+			return err
+		}
+
+		return d.Replace(profile, key, value)
+	}
+
+	node, err := d.profile(profile)
+	if err != nil {
+		return err
+	}
+
+	env := lookup(node.Value, "env")
+
+	if env == nil {
+		if err := d.open(node); err != nil {
+			return fmt.Errorf("profile %q: %w", profile, err)
+		}
+
+		indent := d.childIndent(node)
+
+		d.lines.insert(d.blockEnd(lineOf(node)), pad(indent)+"env:\n", pad(indent+d.unit())+entry(key, value)+"\n")
+
+		return d.parse()
+	}
+
+	if err := d.open(env); err != nil {
+		return fmt.Errorf("profile %q: env: %w", profile, err)
+	}
+
+	text := entry(key, value)
+
+	if _, ok := env.Value.(*ast.SequenceNode); ok {
+		text = "- " + yamlScalar(key+"="+value)
+	}
+
+	d.lines.insert(d.blockEnd(lineOf(env)), pad(d.childIndent(env))+text+"\n")
+
+	return d.parse()
+}
+
+// Unset removes the env variable from the profile, along with the comments directly above it.
+func (d *yamlDocument) Unset(profile, key string) error {
+	entry, err := d.entry(profile, key)
+	if err != nil {
+		return err
+	}
+
+	env, _ := d.env(profile)
+	if flow(env) {
+		return fmt.Errorf("profile %q: env: %w", profile, errFlow)
+	}
+
+	if entry.item != nil {
+		d.lines.remove(lineOf(entry.item), d.blockEnd(lineOf(entry.item)), true)
+	} else {
+		d.lines.remove(lineOf(entry.value), d.blockEnd(lineOf(entry.value)), true)
+	}
+
+	return d.parse()
+}
+
+// Extends returns the extends entries of the profile.
+func (d *yamlDocument) Extends(profile string) ([]string, error) {
+	node, err := d.profile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	extends := lookup(node.Value, "extends")
+	if extends == nil {
+		return nil, nil
+	}
+
+	sequence, ok := extends.Value.(*ast.SequenceNode)
+	if !ok {
+		return nil, fmt.Errorf("profile %q: extends: %w", profile, ErrUnsupported)
+	}
+
+	entries := make([]string, 0, len(sequence.Values))
+
+	for _, item := range sequence.Values {
+		entries = append(entries, scalar(item))
+	}
+
+	return entries, nil
+}
+
+// SetExtends replaces the extends entries of the profile, removing them if empty.
+// Existing lists keep their flow or block style, new ones are added in flow style as the first key of the profile.
+func (d *yamlDocument) SetExtends(profile string, entries []string) error {
+	node, err := d.profile(profile)
+	if err != nil {
+		return err
+	}
+
+	extends := lookup(node.Value, "extends")
+
+	switch {
+	case extends == nil && len(entries) == 0:
+		return nil
+	case extends == nil:
+		if err := d.open(node); err != nil {
+			return fmt.Errorf("profile %q: %w", profile, err)
+		}
+
+		d.lines.insert(lineOf(node), pad(d.childIndent(node))+"extends: "+flowSequence(entries)+"\n")
+	case len(entries) == 0:
+		d.lines.remove(lineOf(extends), d.blockEnd(lineOf(extends)), false)
+	case flow(extends.Value) || !isSequence(extends.Value):
+		line, start, end, err := d.inline(extends)
+		if err != nil {
+			return fmt.Errorf("profile %q: extends: %w", profile, err)
+		}
+
+		runes := []rune(d.lines[line])
+
+		d.lines[line] = strings.TrimRight(string(runes[:start]), " ") + " " + flowSequence(entries) + string(runes[end:])
+	default:
+		first := lineOf(extends)
+		indent := d.childIndent(extends)
+
+		items := make([]string, 0, len(entries))
+
+		for _, entry := range entries {
+			items = append(items, pad(indent)+"- "+quoted(entry)+"\n")
+		}
+
+		d.lines.remove(first+1, d.blockEnd(first), false)
+		d.lines.insert(first, items...)
+	}
+
+	return d.parse()
+}
+
+// AddProfile adds an empty profile at the end.
+func (d *yamlDocument) AddProfile(name string) error {
+	d.lines.append(quoted(name) + ": {}\n")
+
+	return d.parse()
+}
+
+// CopyProfile adds a copy of the profile under a new name, after the profile.
+func (d *yamlDocument) CopyProfile(from, to string) error {
+	node, err := d.profile(from)
+	if err != nil {
+		return err
+	}
+
+	first, last := lineOf(node), d.blockEnd(lineOf(node))
+
+	copied := slices.Clone(d.lines[first : last+1])
+
+	copied[0], err = renameKey(copied[0], node, to)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(copied[len(copied)-1], "\n") {
+		copied[len(copied)-1] += "\n"
+	}
+
+	d.lines.insert(last, append([]string{"\n"}, copied...)...)
+
+	if err := d.parse(); err != nil {
+		return err
+	}
+
+	// The copy must not become a second default profile.
+	node, err = d.profile(to)
+	if err != nil {
+		return err
+	}
+
+	if def := lookup(node.Value, "default"); def != nil && !flow(node.Value) {
+		d.lines.remove(lineOf(def), d.blockEnd(lineOf(def)), false)
+
+		return d.parse()
+	}
+
+	return nil
+}
+
+// RenameProfile renames the profile, without updating references to it.
+func (d *yamlDocument) RenameProfile(from, to string) error {
+	node, err := d.profile(from)
+	if err != nil {
+		return err
+	}
+
+	line := lineOf(node)
+
+	if d.lines[line], err = renameKey(d.lines[line], node, to); err != nil {
+		return err
+	}
+
+	return d.parse()
+}
+
+// DeleteProfile removes the profile, along with the comments directly above it.
+func (d *yamlDocument) DeleteProfile(name string) error {
+	node, err := d.profile(name)
+	if err != nil {
+		return err
+	}
+
+	d.lines.remove(lineOf(node), d.blockEnd(lineOf(node)), true)
+
+	return d.parse()
+}
+
+// errFlow is returned when a flow style mapping or sequence would need to be changed structurally.
+var errFlow = errors.New("flow style mappings and sequences with entries cannot be edited, use block style")
+
+// yamlEntry locates an env variable, either a key of the env mapping or an item of the env sequence.
+type yamlEntry struct {
+	// value is the mapping value of the variable, if env is a mapping.
+	value *ast.MappingValueNode
+	// item is the "KEY=VALUE" item of the variable, if env is a sequence.
+	item ast.Node
+}
+
+// entry locates the env variable of the profile.
+func (d *yamlDocument) entry(profile, key string) (yamlEntry, error) {
+	env, err := d.env(profile)
+	if err != nil {
+		return yamlEntry{}, err
+	}
+
+	if sequence, ok := env.(*ast.SequenceNode); ok {
+		for _, item := range sequence.Values {
+			if name, _, _ := strings.Cut(scalar(item), "="); strings.TrimSpace(name) == key {
+				return yamlEntry{item: item}, nil
+			}
+		}
+
+		return yamlEntry{}, notFound(profile, key)
+	}
+
+	if value := lookup(env, key); value != nil {
+		return yamlEntry{value: value}, nil
+	}
+
+	return yamlEntry{}, notFound(profile, key)
+}
+
+// profile returns the mapping value of the profile.
+func (d *yamlDocument) profile(name string) (*ast.MappingValueNode, error) {
+	var node *ast.MappingValueNode
+
+	if len(d.file.Docs) > 0 {
+		node = lookup(d.file.Docs[0].Body, name)
+	}
+
+	if node == nil {
+		return nil, fmt.Errorf("profile %q: %w", name, ErrNotFound)
+	}
+
+	return node, nil
+}
+
+// env returns the env node of the profile.
+func (d *yamlDocument) env(profile string) (ast.Node, error) {
+	node, err := d.profile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	env := lookup(node.Value, "env")
+	if env == nil {
+		return nil, notFound(profile, "")
+	}
+
+	return env.Value, nil
+}
+
+// open prepares a mapping value for adding entries below its key:
+// an empty flow mapping or sequence, or an explicit null, is removed from the key line.
+func (d *yamlDocument) open(node *ast.MappingValueNode) error {
+	line, start, end, err := d.inline(node)
+	if err != nil {
+		return err
+	}
+
+	runes := []rune(d.lines[line])
+
+	switch value := strings.TrimSpace(string(runes[start:end])); value {
+	case "":
+		return nil
+	case "{}", "[]", "null", "~":
+		d.lines[line] = strings.TrimRight(string(runes[:start]), " ") + string(runes[end:])
+
+		return nil
+	default:
+		return errFlow
+	}
+}
+
+// inline returns the line of the key of the mapping value, and the start and end of the value on that line,
+// excluding a trailing comment. The value must end on the line.
+func (d *yamlDocument) inline(node *ast.MappingValueNode) (int, int, int, error) {
+	line := lineOf(node)
+	runes := []rune(d.lines[line])
+
+	colon, ok := keyEnd(runes, node.Key.GetToken().Position.Column-1)
+	if !ok || colon >= len(runes) || runes[colon] != ':' {
+		return 0, 0, 0, ErrUnsupported
+	}
+
+	start := colon + 1
+
+	for start < len(runes) && (runes[start] == ' ' || runes[start] == '\t') {
+		start++
+	}
+
+	if start == len(runes) || runes[start] == '\n' || runes[start] == '\r' || runes[start] == '#' {
+		return line, start, start, nil
+	}
+
+	end, ok := scalarEnd(runes, start)
+	value := string(runes[start:end])
+
+	if !ok || strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") ||
+		strings.HasPrefix(value, "{") && !strings.HasSuffix(value, "}") {
+		return 0, 0, 0, ErrUnsupported
+	}
+
+	return line, start, end, nil
+}
+
+// keyEnd returns the end of the key starting at start, which for plain keys is the separating colon.
+func keyEnd(line []rune, start int) (int, bool) {
+	if quote := line[start]; quote == '"' || quote == '\'' {
+		end, ok := scalarEnd(line, start)
+
+		for ok && end < len(line) && line[end] == ' ' {
+			end++
+		}
+
+		return end, ok
+	}
+
+	for i := start; i < len(line); i++ {
+		if line[i] == ':' && (i+1 == len(line) || strings.ContainsRune(" \t\r\n", line[i+1])) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// blockEnd returns the index of the last line of the block starting on the given line:
+// the following lines indented deeper, or for keys, sequence items at the same indentation.
+// Trailing blank and comment lines are not part of the block.
+func (d *yamlDocument) blockEnd(start int) int {
+	indent := indentation(d.lines[start])
+	item := strings.HasPrefix(strings.TrimSpace(d.lines[start]), "-")
+	end := start
+
+	for i := start + 1; i < len(d.lines); i++ {
+		line := d.lines[i]
+
+		switch {
+		case blank(line):
+			continue
+		case indentation(line) > indent,
+			!item && indentation(line) == indent && strings.HasPrefix(strings.TrimSpace(line), "-"):
+			end = i
+		default:
+			return end
+		}
+	}
+
+	return end
+}
+
+// childIndent returns the indentation of the entries of the mapping value,
+// from its first entry if any, or one unit deeper than its key.
+func (d *yamlDocument) childIndent(node *ast.MappingValueNode) int {
+	if end := d.blockEnd(lineOf(node)); end > lineOf(node) {
+		for _, line := range d.lines[lineOf(node)+1 : end+1] {
+			if !blank(line) {
+				return indentation(line)
+			}
+		}
+	}
+
+	return indentation(d.lines[lineOf(node)]) + d.unit()
+}
+
+// unit returns the indentation unit of the file, from its first indented line, defaulting to 2.
+func (d *yamlDocument) unit() int {
+	for _, line := range d.lines {
+		if !blank(line) && indentation(line) > 0 {
+			return indentation(line)
+		}
+	}
+
+	//nolint:mnd	// Default indentation.
+	return 2
+}
+
+// renameKey returns the line of the mapping value with its key replaced.
+func renameKey(line string, node *ast.MappingValueNode, key string) (string, error) {
+	runes := []rune(line)
+	start := node.Key.GetToken().Position.Column - 1
+
+	end, ok := keyEnd(runes, start)
+	if !ok {
+		return "", ErrUnsupported
+	}
+
+	if quote := runes[start]; quote == '"' || quote == '\'' {
+		end, _ = scalarEnd(runes, start)
+	}
+
+	return string(runes[:start]) + quoted(key) + string(runes[end:]), nil
+}
+
+// lineOf returns the index of the line the node starts on.
+func lineOf(node ast.Node) int {
+	if value, ok := node.(*ast.MappingValueNode); ok {
+		node = value.Key
+	}
+
+	return node.GetToken().Position.Line - 1
+}
+
+// isSequence reports whether the node is a sequence.
+func isSequence(node ast.Node) bool {
+	_, ok := node.(*ast.SequenceNode)
+
+	return ok
+}
+
+// flow reports whether the node is a flow style mapping or sequence.
+func flow(node ast.Node) bool {
+	switch node := node.(type) {
+	case *ast.MappingNode:
+		return node.IsFlowStyle
+	case *ast.SequenceNode:
+		return node.IsFlowStyle
+	}
+
+	return false
+}
+
+// entry formats a "key: value" mapping entry.
+func entry(key, value string) string {
+	return quoted(key) + ": " + yamlScalar(value)
+}
+
+// quoted encodes the string as a YAML scalar, quoted where needed.
+// Strings with control characters are double-quoted with escapes, as plain scalars would lose tabs and line breaks.
+func quoted(value string) string {
+	if strings.ContainsFunc(value, unicode.IsControl) {
+		return strconv.Quote(value)
+	}
+
+	node, err := yaml.ValueToNode(value)
+	if err != nil {
+		return strconv.Quote(value)
+	}
+
+	return node.String()
+}
+
+// flowSequence formats the entries as a flow style sequence.
+func flowSequence(entries []string) string {
+	items := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		items = append(items, quoted(entry))
+	}
+
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// pad returns the indentation of the given width.
+func pad(width int) string {
+	return strings.Repeat(" ", width)
+}
+
+// lookup returns the mapping value with the given key, or nil if the node is not a mapping or has no such key.
+func lookup(node ast.Node, key string) *ast.MappingValueNode {
+	var values []*ast.MappingValueNode
+
+	switch node := node.(type) {
+	case *ast.MappingNode:
+		values = node.Values
+	case *ast.MappingValueNode:
+		values = []*ast.MappingValueNode{node}
+	}
+
+	for _, value := range values {
+		if scalar(value.Key) == key {
+			return value
+		}
+	}
+
+	return nil
+}
+
+// scalar returns the unquoted text of a scalar node.
+func scalar(node ast.Node) string {
+	if node == nil {
+		return ""
+	}
+
+	if token := node.GetToken(); token != nil {
+		return token.Value
+	}
+
+	return ""
+}
@@ -0,0 +1,204 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Status classifies a key across several environments.
+type Status string
+
+const (
+	// Identical means the key has the same value in all environments.
+	Identical Status = "identical"
+	// Different means the key is present in all environments, with differing values.
+	Different Status = "different"
+	// Missing means the key is absent from some environments.
+	Missing Status = "missing"
+)
+
+// Symbol returns a single character marker for the status.
+func (s Status) Symbol() string {
+	switch s {
+	case Different:
+		return "~"
+	case Missing:
+		return "!"
+	default:
+		return "="
+	}
+}
+
+// Row holds the values of a single key across environments.
+type Row struct {
+	// Key is the environment variable name.
+	Key string `json:"key"`
+	// Status classifies the key across the environments.
+	Status Status `json:"status"`
+	// Values are the values in the order of the environments, nil where an environment lacks the key.
+	// They are kept by position, as several sources may have the same name.
+	Values []*string `json:"values"`
+}
+
+// Matrix is a key-by-environment comparison of several environments.
+type Matrix struct {
+	// Names are the compared environments, in order.
+	Names []string `json:"profiles"`
+	// Rows are the keys of all environments, sorted.
+	Rows []Row `json:"rows"`
+}
+
+// Compare builds a comparison matrix of the given environments.
+func Compare(environments ...Environment) Matrix {
+	matrix := Matrix{}
+
+	keys := map[string]bool{}
+
+	for _, environment := range environments {
+		matrix.Names = append(matrix.Names, environment.Name)
+
+		for _, key := range environment.Env.Keys() {
+			keys[key] = true
+		}
+	}
+
+	for key := range keys {
+		row := Row{Key: key, Status: Identical, Values: make([]*string, len(environments))}
+
+		for i, environment := range environments {
+			if !environment.Env.Exists(key) {
+				row.Status = Missing
+
+				continue
+			}
+
+			value := environment.Env.Get(key)
+
+			for _, other := range row.Values[:i] {
+				if other != nil && *other != value && row.Status == Identical {
+					row.Status = Different
+				}
+			}
+
+			row.Values[i] = &value
+		}
+
+		matrix.Rows = append(matrix.Rows, row)
+	}
+
+	slices.SortFunc(matrix.Rows, func(x, y Row) int { return strings.Compare(x.Key, y.Key) })
+
+	return matrix
+}
+
+// Differences returns the matrix without the keys that are identical in all environments.
+func (m Matrix) Differences() Matrix {
+	m.Rows = slices.DeleteFunc(slices.Clone(m.Rows), func(row Row) bool { return row.Status == Identical })
+
+	return m
+}
+
+// cells returns the displayed values of a row in the order of the environments,
+// with unset in place of missing values.
+func (m Matrix) cells(row Row, unset string, escape func(string) string) []string {
+	cells := make([]string, 0, len(row.Values))
+
+	for _, value := range row.Values {
+		if value == nil {
+			cells = append(cells, unset)
+
+			continue
+		}
+
+		cells = append(cells, escape(*value))
+	}
+
+	return cells
+}
+
+// Text renders the matrix as an aligned table, marking each key with its status symbol.
+func (m Matrix) Text() string {
+	var builder strings.Builder
+
+	//nolint:mnd 	// minwidth, tabwidth, padding, padchar, flags
+	writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+
+	escape := strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`).Replace
+
+	_, _ = fmt.Fprintf(writer, " \tKEY\t%s\n", strings.Join(m.Names, "\t"))
+
+	for _, row := range m.Rows {
+		cells := m.cells(row, "<unset>", escape)
+
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\n", row.Status.Symbol(), row.Key, strings.Join(cells, "\t"))
+	}
+
+	_ = writer.Flush()
+
+	return builder.String()
+}
+
+// Markdown renders the matrix as a Markdown table with a status column.
+func (m Matrix) Markdown() string {
+	var builder strings.Builder
+
+	escape := strings.NewReplacer("|", `\|`, "\n", "<br>", "\r", "").Replace
+
+	builder.WriteString("| STATUS | KEY | " + strings.Join(m.Names, " | ") + " |\n")
+	builder.WriteString("| --- | --- |" + strings.Repeat(" --- |", len(m.Names)) + "\n")
+
+	for _, row := range m.Rows {
+		cells := m.cells(row, "*unset*", func(value string) string { return code(escape(value)) })
+
+		builder.WriteString(
+			"| " + string(row.Status) + " | " + escape(row.Key) + " | " + strings.Join(cells, " | ") + " |\n",
+		)
+	}
+
+	return builder.String()
+}
+
+// code renders the value as a Markdown code span, fenced with more backticks than any run of them in the value.
+// Values starting or ending with a backtick, or with a space on both ends, are padded with a space on each side,
+// which Markdown strips again. An empty value is rendered as a single space, as an empty code span is literal.
+func code(value string) string {
+	longest, run := 0, 0
+
+	for _, char := range value {
+		if char == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+
+	fence := strings.Repeat("`", longest+1)
+
+	switch {
+	case value == "":
+		value = " "
+	case strings.HasPrefix(value, "`") || strings.HasSuffix(value, "`"),
+		strings.HasPrefix(value, " ") && strings.HasSuffix(value, " ") && strings.Trim(value, " ") != "":
+		value = " " + value + " "
+	}
+
+	return fence + value + fence
+}
+
+// JSON renders the matrix as indented JSON.
+func (m Matrix) JSON() (string, error) {
+	if m.Rows == nil {
+		m.Rows = []Row{}
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
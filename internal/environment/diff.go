@@ -1,31 +1,38 @@
 package environment
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"path/filepath"
 	"slices"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/idelchi/envprof/pkg/dotenv"
 	"github.com/idelchi/godyl/pkg/env"
 )
 
 // Change represents a single environment variable that has changed between two environments.
 type Change struct {
 	// Key is the environment variable name.
-	Key string
+	Key string `json:"key"`
 	// Old is the previous value of the variable.
-	Old string
+	Old string `json:"old"`
 	// New is the current value of the variable.
-	New string
+	New string `json:"new"`
 }
 
 // Diff represents the differences between two environments.
 type Diff struct {
 	// Added contains variables present in B but not in A.
-	Added env.Env
+	Added env.Env `json:"added"`
 	// Removed contains variables present in A but not in B.
-	Removed env.Env
+	Removed env.Env `json:"removed"`
 	// Changed contains variables present in both with different values.
-	Changed []Change
+	Changed []Change `json:"changed"`
+	// Unchanged contains variables present in both with the same value.
+	Unchanged env.Env `json:"-"`
 }
 
 // Diffs computes a structured diff of two environments.
@@ -35,11 +42,13 @@ func Diffs(first, second env.Env) Diff {
 	second = second.Normalized()
 
 	out := Diff{
-		Added:   make(env.Env),
-		Removed: make(env.Env),
+		Added:     make(env.Env),
+		Removed:   make(env.Env),
+		Changed:   []Change{},
+		Unchanged: make(env.Env),
 	}
 
-	// Removed / Changed
+	// Removed / Changed / Unchanged
 	for _, key := range first.Keys() {
 		oldValue := first.Get(key)
 		if !second.Exists(key) {
@@ -50,6 +59,8 @@ func Diffs(first, second env.Env) Diff {
 
 		if bv := second.Get(key); oldValue != bv {
 			out.Changed = append(out.Changed, Change{Key: key, Old: oldValue, New: bv})
+		} else {
+			out.Unchanged[key] = oldValue
 		}
 	}
 
@@ -71,27 +82,314 @@ func (d Diff) Equal() bool {
 	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
 }
 
-// Render prints a git-like unified diff.
-// Lines: + added, - removed, ~ changed ("old -> new").
+// Filter returns the diff restricted to the keys matching any of the include patterns (all keys if none),
+// and none of the exclude patterns. Patterns use the syntax of filepath.Match.
+func (d Diff) Filter(include, exclude []string) (Diff, error) {
+	for _, pattern := range slices.Concat(include, exclude) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return d, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	keep := func(key string) bool {
+		matches := func(pattern string) bool {
+			ok, _ := filepath.Match(pattern, key)
+
+			return ok
+		}
+
+		return (len(include) == 0 || slices.ContainsFunc(include, matches)) && !slices.ContainsFunc(exclude, matches)
+	}
+
+	filter := func(e env.Env) env.Env {
+		out := make(env.Env)
+
+		for key, value := range e {
+			if keep(key) {
+				out[key] = value
+			}
+		}
+
+		return out
+	}
+
+	d.Added = filter(d.Added)
+	d.Removed = filter(d.Removed)
+	d.Unchanged = filter(d.Unchanged)
+	d.Changed = slices.DeleteFunc(slices.Clone(d.Changed), func(change Change) bool { return !keep(change.Key) })
+
+	return d, nil
+}
+
+// DiffFormat is a rendering format for diffs.
+type DiffFormat string
+
+const (
+	// Simple renders one line per difference: + added, - removed, ~ changed ("old -> new").
+	Simple DiffFormat = "simple"
+	// Unified renders a unified diff of the environments as sorted dotenv lines.
+	Unified DiffFormat = "unified"
+	// SideBySide renders all keys in two columns, marked as in diff(1) --side-by-side.
+	SideBySide DiffFormat = "side-by-side"
+	// JSONDiff renders the differences as JSON.
+	JSONDiff DiffFormat = "json"
+)
+
+// DiffFormats returns all supported diff formats.
+func DiffFormats() []DiffFormat {
+	return []DiffFormat{Simple, Unified, SideBySide, JSONDiff}
+}
+
+// RenderOptions controls how a diff is rendered.
+type RenderOptions struct {
+	// Format is the rendering format, Simple if empty.
+	Format DiffFormat
+	// Color enables ANSI colors.
+	Color bool
+}
+
+// ANSI escape sequences used for coloring diffs.
+const (
+	red    = "\x1b[31m"
+	green  = "\x1b[32m"
+	yellow = "\x1b[33m"
+	cyan   = "\x1b[36m"
+	bold   = "\x1b[1m"
+	reset  = "\x1b[0m"
+)
+
+// paint wraps text in the given ANSI color, if enabled.
+func (o RenderOptions) paint(color, text string) string {
+	if !o.Color {
+		return text
+	}
+
+	return color + text + reset
+}
+
+// Render writes the diff to w in the selected format.
 // aName/bName are labels (e.g., "env1", "env2").
-//
-//nolint:forbidigo	// Function prints out to the console.
-func (d Diff) Render(aName, bName string) error {
-	fmt.Printf("--- %s\n+++ %s\n", aName, bName)
+func (d Diff) Render(w io.Writer, aName, bName string, options RenderOptions) error {
+	var lines []string
+
+	switch options.Format {
+	case Simple, "":
+		lines = d.simple(aName, bName, options)
+	case Unified:
+		lines = d.unified(aName, bName, options)
+	case SideBySide:
+		return d.sideBySide(w, aName, bName, options)
+	case JSONDiff:
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		lines = []string{string(data)}
+	default:
+		return fmt.Errorf("unsupported diff format %q, must be one of %v", options.Format, DiffFormats())
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
 
-	addKeys := d.Added.Keys()
-	rmKeys := d.Removed.Keys()
+	return nil
+}
 
-	for _, k := range rmKeys {
-		fmt.Printf("%s %s=%q\n", "-", k, d.Removed[k])
+// simple renders the bespoke format.
+func (d Diff) simple(aName, bName string, options RenderOptions) []string {
+	lines := []string{options.paint(bold, "--- "+aName), options.paint(bold, "+++ "+bName)}
+
+	for _, k := range d.Removed.Keys() {
+		lines = append(lines, options.paint(red, fmt.Sprintf("%s %s=%q", "-", k, d.Removed[k])))
 	}
 
-	for _, k := range addKeys {
-		fmt.Printf("%s %s=%q\n", "+", k, d.Added[k])
+	for _, k := range d.Added.Keys() {
+		lines = append(lines, options.paint(green, fmt.Sprintf("%s %s=%q", "+", k, d.Added[k])))
 	}
 
 	for _, ch := range d.Changed {
-		fmt.Printf("%s %s: %q -> %q\n", "~", ch.Key, ch.Old, ch.New)
+		lines = append(lines, options.paint(yellow, fmt.Sprintf("%s %s: %q -> %q", "~", ch.Key, ch.Old, ch.New)))
+	}
+
+	return lines
+}
+
+// keys returns all keys of the compared environments, sorted.
+func (d Diff) keys() []string {
+	keys := slices.Concat(d.Added.Keys(), d.Removed.Keys(), d.Unchanged.Keys())
+
+	for _, ch := range d.Changed {
+		keys = append(keys, ch.Key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// changes indexes the changed variables by key.
+func (d Diff) changes() map[string]Change {
+	changes := make(map[string]Change, len(d.Changed))
+
+	for _, ch := range d.Changed {
+		changes[ch.Key] = ch
+	}
+
+	return changes
+}
+
+// unified renders a unified diff with three lines of context, treating each environment
+// as its sorted dotenv lines.
+func (d Diff) unified(aName, bName string, options RenderOptions) []string {
+	type edit struct {
+		op   byte
+		text string
+	}
+
+	changes := d.changes()
+
+	// text renders a variable as a dotenv line, keeping multi-line values on a single line.
+	text := func(key, value string) string {
+		return strings.NewReplacer("\n", `\n`, "\r", `\r`).Replace(dotenv.Line(key, value))
+	}
+
+	var edits []edit
+
+	for _, key := range d.keys() {
+		switch {
+		case d.Unchanged.Exists(key):
+			edits = append(edits, edit{' ', text(key, d.Unchanged[key])})
+		case d.Removed.Exists(key):
+			edits = append(edits, edit{'-', text(key, d.Removed[key])})
+		case d.Added.Exists(key):
+			edits = append(edits, edit{'+', text(key, d.Added[key])})
+		default:
+			edits = append(edits,
+				edit{'-', text(key, changes[key].Old)},
+				edit{'+', text(key, changes[key].New)},
+			)
+		}
+	}
+
+	lines := []string{options.paint(bold, "--- "+aName), options.paint(bold, "+++ "+bName)}
+
+	const context = 3
+
+	// Merge the context windows around each edit into hunks.
+	var hunks [][2]int
+
+	for i, e := range edits {
+		if e.op == ' ' {
+			continue
+		}
+
+		start, end := max(i-context, 0), min(i+context+1, len(edits))
+
+		if n := len(hunks); n > 0 && start <= hunks[n-1][1] {
+			hunks[n-1][1] = end
+		} else {
+			hunks = append(hunks, [2]int{start, end})
+		}
+	}
+
+	// count returns the number of lines of the first (excluding '+') or second (excluding '-') environment.
+	count := func(edits []edit, exclude byte) (n int) {
+		for _, e := range edits {
+			if e.op != exclude {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	// position returns the 1-based start line of a range, or the preceding line for empty ranges.
+	position := func(before, length int) int {
+		if length == 0 {
+			return before
+		}
+
+		return before + 1
+	}
+
+	for _, hunk := range hunks {
+		aBefore, aLength := count(edits[:hunk[0]], '+'), count(edits[hunk[0]:hunk[1]], '+')
+		bBefore, bLength := count(edits[:hunk[0]], '-'), count(edits[hunk[0]:hunk[1]], '-')
+
+		lines = append(lines, options.paint(cyan, fmt.Sprintf(
+			"@@ -%d,%d +%d,%d @@",
+			position(aBefore, aLength), aLength,
+			position(bBefore, bLength), bLength,
+		)))
+
+		for _, e := range edits[hunk[0]:hunk[1]] {
+			line := string(e.op) + e.text
+
+			switch e.op {
+			case '-':
+				line = options.paint(red, line)
+			case '+':
+				line = options.paint(green, line)
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// sideBySide renders all keys with their values in two columns,
+// marked with '<' (removed), '>' (added), '|' (changed) or nothing (unchanged).
+func (d Diff) sideBySide(w io.Writer, aName, bName string, options RenderOptions) error {
+	var builder strings.Builder
+
+	//nolint:mnd 	// minwidth, tabwidth, padding, padchar, flags
+	writer := tabwriter.NewWriter(&builder, 0, 4, 2, ' ', 0)
+
+	escape := strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`).Replace
+	changes := d.changes()
+
+	_, _ = fmt.Fprintf(writer, "KEY\t%s\t \t%s\n", aName, bName)
+
+	// Colors are applied per line after alignment, since escape sequences would break the columns.
+	colors := []string{bold}
+
+	for _, key := range d.keys() {
+		var left, right, marker, color string
+
+		switch {
+		case d.Unchanged.Exists(key):
+			left, right, marker = d.Unchanged[key], d.Unchanged[key], " "
+		case d.Removed.Exists(key):
+			left, marker, color = d.Removed[key], "<", red
+		case d.Added.Exists(key):
+			right, marker, color = d.Added[key], ">", green
+		default:
+			left, right, marker, color = changes[key].Old, changes[key].New, "|", yellow
+		}
+
+		colors = append(colors, color)
+
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", key, escape(left), marker, escape(right))
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	for i, line := range strings.Split(strings.TrimSuffix(builder.String(), "\n"), "\n") {
+		if colors[i] != "" {
+			line = options.paint(colors[i], line)
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
 	}
 
 	return nil
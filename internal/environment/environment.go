@@ -1,10 +1,10 @@
 package environment
 
 import (
-	"errors"
 	"fmt"
-	"strings"
+	"os"
 
+	"github.com/idelchi/envprof/pkg/dotenv"
 	"github.com/idelchi/godyl/pkg/env"
 	"github.com/idelchi/godyl/pkg/path/file"
 )
@@ -15,23 +15,30 @@ type Environment struct {
 	Name string
 	// Output is the output file for the profile.
 	Output file.File
+	// Format is the output format, inferred from Output if empty.
+	Format Format
+	// Mode is the file mode of the output file, 0 to use the default.
+	Mode os.FileMode
 
 	// Env is the environment variables for the profile.
 	Env env.Env
 	// Origin tracks the source of each environment variable.
 	Origin Origin
+	// Sensitive marks environments with decrypted values.
+	Sensitive bool
 }
 
 // New returns a new environment for the given profile,
-// with default values for the output file.
-func New(name, output string) Environment {
+// with default values for the output file based on the format.
+func New(name, output string, format Format) Environment {
 	if output == "" {
-		output = name + ".env"
+		output = name + format.Extension()
 	}
 
 	return Environment{
 		Name:   name,
 		Output: file.New(output),
+		Format: format,
 		Env:    make(env.Env),
 		Origin: make(Origin),
 	}
@@ -49,7 +56,7 @@ func (e *Environment) OverlayDotEnv(path, profile string) error {
 		return fmt.Errorf("dotenv file %q is a directory", path)
 	}
 
-	env, err := env.FromDotEnv(file.Path())
+	env, err := dotenv.Read(file.Path(), e.lookup)
 	if err != nil {
 		return err
 	}
@@ -63,6 +70,16 @@ func (e *Environment) OverlayDotEnv(path, profile string) error {
 	return nil
 }
 
+// lookup resolves variables referenced in dotenv files against the environment built so far,
+// falling back to the process environment.
+func (e *Environment) lookup(key string) (string, bool) {
+	if !e.Env.Exists(key) {
+		return os.LookupEnv(key)
+	}
+
+	return e.Env.Get(key), true
+}
+
 // UpdateOrigin updates the origin of the environment variables.
 func (e *Environment) UpdateOrigin(profile string, env env.Env) {
 	if e.Name == profile {
@@ -81,22 +98,5 @@ func (e *Environment) OverlayOther(other Environment) {
 	e.UpdateOrigin(profile, env)
 
 	e.Env = other.Env.MergedWith(e.Env)
-}
-
-// Write saves the environment variables to a dotenv file.
-func (e *Environment) Write() error {
-	if e.Output == "" {
-		return errors.New("no output file specified")
-	}
-
-	envs := e.Env.AsSlice()
-
-	envs = append([]string{fmt.Sprintf("# Active profile: %q", e.Name)}, envs...)
-	envs = append(envs, "")
-
-	if err := e.Output.Write([]byte(strings.Join(envs, "\n"))); err != nil {
-		return fmt.Errorf("writing to dotenv file %q: %w", e.Output, err)
-	}
-
-	return nil
+	e.Sensitive = e.Sensitive || other.Sensitive
 }
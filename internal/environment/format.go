@@ -3,6 +3,8 @@ package environment
 import (
 	"fmt"
 	"strings"
+
+	"github.com/idelchi/envprof/pkg/dotenv"
 )
 
 // Formatter holds the configuration for formatting environment variables.
@@ -15,9 +17,39 @@ type Formatter struct {
 	Prefix string
 	// Padding is the width of the output field.
 	Padding int
+	// Quote quotes the value when the key is included, defaulting to dotenv quoting.
+	Quote Quoter
+}
+
+// Quoter quotes a value for the syntax it is printed in.
+type Quoter func(value string) string
+
+// ExportQuoter returns the quoter for export lines starting with prefix,
+// quoting for PowerShell with the '$env:' prefix and for POSIX shells otherwise.
+func ExportQuoter(prefix string) Quoter {
+	if strings.HasPrefix(strings.ToLower(prefix), "$env:") {
+		return powerShellQuote
+	}
+
+	return shellQuote
+}
+
+// powerShellQuote quotes a value as a PowerShell verbatim string, doubling the single quotes within,
+// including the typographic ones PowerShell accepts as well.
+func powerShellQuote(value string) string {
+	replacer := strings.NewReplacer(
+		"'", "''",
+		"\u2018", "\u2018\u2018",
+		"\u2019", "\u2019\u2019",
+		"\u201a", "\u201a\u201a",
+		"\u201b", "\u201b\u201b",
+	)
+
+	return "'" + replacer.Replace(value) + "'"
 }
 
 // Key formats an environment variable for output.
+// With the key included, the value is quoted by Quote, or as in a dotenv file.
 func (f Formatter) Key(key string, environment Environment) string {
 	if f.Padding == 0 {
 		f.Padding = 60
@@ -26,7 +58,12 @@ func (f Formatter) Key(key string, environment Environment) string {
 	val := environment.Env.Get(key)
 
 	if f.WithKey {
-		val = fmt.Sprintf("%v=%v", key, val)
+		quote := f.Quote
+		if quote == nil {
+			quote = dotenv.Quote
+		}
+
+		val = key + "=" + quote(val)
 	}
 
 	if f.WithOrigin {
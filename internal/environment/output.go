@@ -0,0 +1,254 @@
+package environment
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/idelchi/envprof/pkg/dotenv"
+	"github.com/idelchi/godyl/pkg/path/file"
+)
+
+// Format is an output format for writing an environment to a file.
+type Format string
+
+const (
+	// DotEnv writes a dotenv file.
+	DotEnv Format = "dotenv"
+	// JSON writes a JSON object.
+	JSON Format = "json"
+	// YAML writes a YAML mapping.
+	YAML Format = "yaml"
+	// Shell writes a shell script of 'export' lines.
+	Shell Format = "shell"
+	// Systemd writes a systemd 'EnvironmentFile'.
+	Systemd Format = "systemd"
+	// Docker writes a file for 'docker run --env-file', with unquoted values.
+	Docker Format = "docker"
+	// GitHub writes lines for the GitHub Actions '$GITHUB_ENV' file.
+	GitHub Format = "github"
+	// ConfigMap writes a Kubernetes ConfigMap manifest.
+	ConfigMap Format = "configmap"
+	// Secret writes a Kubernetes Secret manifest.
+	Secret Format = "secret"
+)
+
+// Formats returns all supported output formats.
+func Formats() []Format {
+	return []Format{DotEnv, JSON, YAML, Shell, Systemd, Docker, GitHub, ConfigMap, Secret}
+}
+
+// Valid checks that the format is supported. The empty format is valid and means "inferred".
+func (f Format) Valid() error {
+	if f == "" || slices.Contains(Formats(), f) {
+		return nil
+	}
+
+	return fmt.Errorf("unsupported output format %q, must be one of %v", f, Formats())
+}
+
+// Extension returns the default file extension for the format, including the leading dot.
+func (f Format) Extension() string {
+	switch f {
+	case JSON:
+		return ".json"
+	case YAML, ConfigMap, Secret:
+		return ".yaml"
+	case Shell:
+		return ".sh"
+	default:
+		return ".env"
+	}
+}
+
+// Infer determines the format from the extension of the output file, defaulting to DotEnv.
+func Infer(output file.File) Format {
+	switch strings.ToLower(output.Extension()) {
+	case "json":
+		return JSON
+	case "yaml", "yml":
+		return YAML
+	case "sh", "bash", "zsh":
+		return Shell
+	default:
+		return DotEnv
+	}
+}
+
+// OutputFormat returns the format to write the environment in,
+// inferring it from the output file if not set explicitly.
+func (e Environment) OutputFormat() Format {
+	if e.Format != "" {
+		return e.Format
+	}
+
+	return Infer(e.Output)
+}
+
+// SetFormat sets the output format.
+// An output file matching the default name of the previous format is renamed to match the new one.
+func (e *Environment) SetFormat(format Format) {
+	if e.Output == file.New(e.Name+e.OutputFormat().Extension()) {
+		e.Output = file.New(e.Name + format.Extension())
+	}
+
+	e.Format = format
+}
+
+// headerPrefix is the start of the header, independent of the profile name.
+const headerPrefix = "# Active profile: "
+
+// Header returns the comment line marking files written by envprof.
+func Header(name string) string {
+	return headerPrefix + strconv.Quote(name)
+}
+
+// Commented reports whether the format supports comments, and therefore carries the header.
+func (f Format) Commented() bool {
+	return f != JSON && f != GitHub
+}
+
+// Render renders the environment in its output format.
+func (e Environment) Render() ([]byte, error) {
+	header := Header(e.Name) + "\n"
+
+	switch format := e.OutputFormat(); format {
+	case DotEnv:
+		return []byte(header + lines(e, dotenv.Line)), nil
+	case JSON:
+		data, err := json.MarshalIndent(e.Env, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return append(data, '\n'), nil
+	case YAML:
+		if len(e.Env) == 0 {
+			return []byte(header + "{}\n"), nil
+		}
+
+		data, err := yaml.Marshal(e.Env)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte(header), data...), nil
+	case Shell:
+		return []byte("#!/bin/sh\n" + header + lines(e, func(key, value string) string {
+			return "export " + key + "=" + shellQuote(value)
+		})), nil
+	case Systemd:
+		return []byte(header + lines(e, func(key, value string) string {
+			return key + "=" + systemdQuote(value)
+		})), nil
+	case Docker:
+		for _, key := range e.Env.Keys() {
+			if strings.ContainsAny(e.Env.Get(key), "\r\n") {
+				return nil, fmt.Errorf("format %q does not support multi-line values: %q", format, key)
+			}
+		}
+
+		return []byte(header + lines(e, func(key, value string) string {
+			return key + "=" + value
+		})), nil
+	case GitHub:
+		return []byte(lines(e, github)), nil
+	case ConfigMap, Secret:
+		return manifest(e, format, header)
+	default:
+		return nil, format.Valid()
+	}
+}
+
+// lines renders each variable with the given function, one per line, sorted by key.
+func lines(e Environment, line func(key, value string) string) string {
+	var builder strings.Builder
+
+	for _, key := range e.Env.Keys() {
+		builder.WriteString(line(key, e.Env.Get(key)) + "\n")
+	}
+
+	return builder.String()
+}
+
+// shellQuote quotes a value for POSIX shells, using single quotes unless the value is safe as is.
+func shellQuote(value string) string {
+	if value != "" && dotenv.Quote(value) == value {
+		return value
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// systemdQuote quotes a value for a systemd 'EnvironmentFile', using double quotes with C-style escapes.
+func systemdQuote(value string) string {
+	if dotenv.Quote(value) == value {
+		return value
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// github renders a variable for the '$GITHUB_ENV' file, using the heredoc syntax for multi-line values.
+func github(key, value string) string {
+	if !strings.ContainsAny(value, "\r\n") {
+		return key + "=" + value
+	}
+
+	delimiter := "ENVPROF_EOF"
+
+	for strings.Contains(value, delimiter) {
+		delimiter += "_"
+	}
+
+	return key + "<<" + delimiter + "\n" + value + "\n" + delimiter
+}
+
+// manifest renders a Kubernetes ConfigMap or Secret manifest named after the profile.
+func manifest(e Environment, format Format, header string) ([]byte, error) {
+	type metadata struct {
+		Name string `yaml:"name"`
+	}
+
+	type object struct {
+		APIVersion string            `yaml:"apiVersion"`
+		Kind       string            `yaml:"kind"`
+		Metadata   metadata          `yaml:"metadata"`
+		Type       string            `yaml:"type,omitempty"`
+		Data       map[string]string `yaml:"data"`
+	}
+
+	out := object{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   metadata{Name: e.Name},
+		Data:       make(map[string]string, len(e.Env)),
+	}
+
+	for key, value := range e.Env {
+		if format == Secret {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+
+		out.Data[key] = value
+	}
+
+	if format == Secret {
+		out.Kind = "Secret"
+		out.Type = "Opaque"
+	}
+
+	data, err := yaml.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("rendering %s manifest: %w", format, err)
+	}
+
+	return append([]byte(header), data...), nil
+}
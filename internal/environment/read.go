@@ -0,0 +1,199 @@
+package environment
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/idelchi/envprof/pkg/dotenv"
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// Parse reads back the variables from content rendered in the given format.
+func Parse(content []byte, format Format) (env.Env, error) {
+	switch format {
+	case DotEnv:
+		return dotenv.Parse(content, nil)
+	case JSON:
+		var out env.Env
+
+		if err := json.Unmarshal(content, &out); err != nil {
+			return nil, err
+		}
+
+		return out, nil
+	case YAML:
+		var out env.Env
+
+		if err := yaml.Unmarshal(content, &out); err != nil {
+			return nil, err
+		}
+
+		return out, nil
+	case ConfigMap, Secret:
+		return parseManifest(content, format)
+	case Shell:
+		return parseLines(content, func(line string, rest *string) (string, string, error) {
+			key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+			if !ok {
+				return "", "", fmt.Errorf("invalid line %q", line)
+			}
+
+			value, err := shellUnquote(value, rest)
+
+			return key, value, err
+		})
+	case Systemd:
+		return parseLines(content, func(line string, _ *string) (string, string, error) {
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return "", "", fmt.Errorf("invalid line %q", line)
+			}
+
+			if strings.HasPrefix(value, `"`) {
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return "", "", fmt.Errorf("invalid value for %q: %w", key, err)
+				}
+
+				value = unquoted
+			}
+
+			return key, value, nil
+		})
+	case Docker:
+		return parseLines(content, func(line string, _ *string) (string, string, error) {
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return "", "", fmt.Errorf("invalid line %q", line)
+			}
+
+			return key, value, nil
+		})
+	case GitHub:
+		return parseLines(content, func(line string, rest *string) (string, string, error) {
+			if key, value, ok := strings.Cut(line, "="); ok {
+				return key, value, nil
+			}
+
+			key, delimiter, ok := strings.Cut(line, "<<")
+			if !ok {
+				return "", "", fmt.Errorf("invalid line %q", line)
+			}
+
+			value, remainder, ok := strings.Cut(*rest, "\n"+delimiter+"\n")
+			if !ok {
+				value, ok = strings.CutSuffix(*rest, "\n"+delimiter)
+				if !ok {
+					return "", "", fmt.Errorf("missing delimiter %q for %q", delimiter, key)
+				}
+			}
+
+			*rest = remainder
+
+			return key, value, nil
+		})
+	default:
+		return nil, format.Valid()
+	}
+}
+
+// parseLines parses content line by line, skipping empty lines and comments.
+// The parse function receives the current line and the remaining content,
+// which it may consume for values spanning multiple lines.
+func parseLines(content []byte, parse func(line string, rest *string) (string, string, error)) (env.Env, error) {
+	out := make(env.Env)
+	rest := string(content)
+
+	for rest != "" {
+		var line string
+
+		line, rest, _ = strings.Cut(rest, "\n")
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, err := parse(line, &rest)
+		if err != nil {
+			return nil, err
+		}
+
+		out[key] = value
+	}
+
+	return out, nil
+}
+
+// shellUnquote reverses shellQuote, consuming further lines from rest for multi-line values.
+func shellUnquote(value string, rest *string) (string, error) {
+	if !strings.HasPrefix(value, "'") {
+		return value, nil
+	}
+
+	remaining := value[1:]
+
+	if *rest != "" {
+		remaining += "\n" + *rest
+	}
+
+	var builder strings.Builder
+
+	for {
+		segment, after, ok := strings.Cut(remaining, "'")
+		if !ok {
+			return "", fmt.Errorf("unterminated quoted value %q", value)
+		}
+
+		builder.WriteString(segment)
+
+		if next, escaped := strings.CutPrefix(after, `\''`); escaped {
+			builder.WriteByte('\'')
+
+			remaining = next
+
+			continue
+		}
+
+		line, remainder, _ := strings.Cut(after, "\n")
+		if line != "" {
+			return "", fmt.Errorf("unexpected characters %q after quoted value", line)
+		}
+
+		*rest = remainder
+
+		return builder.String(), nil
+	}
+}
+
+// parseManifest reads the data of a Kubernetes ConfigMap or Secret manifest.
+func parseManifest(content []byte, format Format) (env.Env, error) {
+	var manifest struct {
+		Data env.Env `yaml:"data"`
+	}
+
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	if manifest.Data == nil {
+		manifest.Data = make(env.Env)
+	}
+
+	if format == Secret {
+		for key, value := range manifest.Data {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("decoding %q: %w", key, err)
+			}
+
+			manifest.Data[key] = string(decoded)
+		}
+	}
+
+	return manifest.Data, nil
+}
@@ -0,0 +1,180 @@
+package environment
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/idelchi/envprof/pkg/atomicfile"
+)
+
+// Result is the outcome of writing an environment to its output file.
+type Result string
+
+const (
+	// Written means the output file was created or updated.
+	Written Result = "written"
+	// Unchanged means the output file already had the rendered content.
+	Unchanged Result = "unchanged"
+	// Skipped means the output file was not written by envprof and was left untouched.
+	Skipped Result = "skipped"
+)
+
+const (
+	// DefaultMode is the file mode for new output files.
+	DefaultMode os.FileMode = 0o644
+	// SecretMode is the file mode for new output files in the Secret format or with decrypted values.
+	SecretMode os.FileMode = 0o600
+)
+
+// WriteOptions controls how an environment is written.
+type WriteOptions struct {
+	// Mode overrides the file mode of the environment, 0 to keep it.
+	Mode os.FileMode
+	// Backup keeps a copy of a replaced file with a '.bak' suffix.
+	Backup bool
+	// Force overwrites existing files lacking the envprof header, or in formats without one.
+	Force bool
+}
+
+// Write saves the environment variables to the output file in the output format.
+// The file is replaced atomically, and only if its content changes.
+// Existing files must start with the header, unless forced. Files in formats without the header
+// cannot be verified to have been written by envprof, and are therefore only replaced when forced.
+// Without an explicit mode, replaced files of private environments lose the permissions of group and others.
+func (e *Environment) Write(options WriteOptions) (Result, error) {
+	if e.Output == "" {
+		return "", errors.New("no output file specified")
+	}
+
+	content, err := e.Render()
+	if err != nil {
+		return "", fmt.Errorf("rendering %q: %w", e.Output, err)
+	}
+
+	path := e.Output.Path()
+
+	mode := options.Mode
+	if mode == 0 {
+		mode = e.Mode
+	}
+
+	info, err := os.Stat(path)
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if mode == 0 {
+			mode = e.defaultMode()
+		}
+
+		if err := atomicfile.Write(path, content, mode); err != nil {
+			return "", err
+		}
+
+		return Written, nil
+	case err != nil:
+		return "", fmt.Errorf("checking %q: %w", path, err)
+	case info.IsDir():
+		return "", fmt.Errorf("output %q is a directory", path)
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading %q: %w", path, err)
+	}
+
+	if mode == 0 {
+		mode = info.Mode().Perm()
+
+		if e.private() {
+			mode &= SecretMode
+		}
+	}
+
+	if bytes.Equal(existing, content) {
+		if info.Mode().Perm() != mode {
+			if err := os.Chmod(path, mode); err != nil {
+				return "", fmt.Errorf("setting permissions for %q: %w", path, err)
+			}
+		}
+
+		return Unchanged, nil
+	}
+
+	if !options.Force && (!e.OutputFormat().Commented() || !hasHeader(existing)) {
+		return Skipped, nil
+	}
+
+	if options.Backup {
+		if err := atomicfile.Write(path+".bak", existing, info.Mode().Perm()); err != nil {
+			return "", fmt.Errorf("backing up %q: %w", path, err)
+		}
+	}
+
+	if err := atomicfile.Write(path, content, mode); err != nil {
+		return "", err
+	}
+
+	return Written, nil
+}
+
+// defaultMode returns the file mode for a new output file.
+func (e *Environment) defaultMode() os.FileMode {
+	if e.private() {
+		return SecretMode
+	}
+
+	return DefaultMode
+}
+
+// private reports whether the output file should only be accessible by its owner,
+// for the Secret format or environments with decrypted values.
+func (e *Environment) private() bool {
+	return e.OutputFormat() == Secret || e.Sensitive
+}
+
+// hasHeader reports whether the header appears among the first two lines, allowing for a shebang.
+func hasHeader(content []byte) bool {
+	//nolint:mnd	// Shebang and header.
+	lines := strings.SplitN(string(content), "\n", 3)
+
+	for _, line := range lines[:min(len(lines), 2)] {
+		if strings.HasPrefix(line, headerPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check compares the output file with the rendered environment, without writing anything.
+// It reports whether the file is up to date, and otherwise the differences from its variables
+// to those of the environment.
+func (e *Environment) Check() (bool, Diff, error) {
+	content, err := e.Render()
+	if err != nil {
+		return false, Diff{}, fmt.Errorf("rendering %q: %w", e.Output, err)
+	}
+
+	path := e.Output.Path()
+
+	existing, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return false, Diffs(nil, e.Env), nil
+	case err != nil:
+		return false, Diff{}, fmt.Errorf("reading %q: %w", path, err)
+	case bytes.Equal(existing, content):
+		return true, Diff{}, nil
+	}
+
+	current, err := Parse(existing, e.OutputFormat())
+	if err != nil {
+		return false, Diff{}, fmt.Errorf("parsing %q: %w", path, err)
+	}
+
+	return false, Diffs(current, e.Env), nil
+}
@@ -1,8 +1,11 @@
 package envprof
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/idelchi/envprof/internal/profiles"
@@ -25,7 +28,11 @@ const (
 type EnvProf struct {
 	file     file.File
 	format   Type
+	hash     string            // SHA-256 hash of the loaded content
+	settings Settings          // loaded file-level settings
 	profiles profiles.Profiles // loaded profiles
+	worktree string            // working tree replaced by checkout, if set
+	checkout string            // checkout of another revision of worktree, if set
 }
 
 // New creates a new EnvProf instance from the given file.
@@ -45,6 +52,12 @@ func NewFrom(files files.Files) (*EnvProf, error) {
 	return New(file), nil
 }
 
+// SetCheckout resolves relative dotenv paths within checkout instead of the working tree at worktree,
+// for content of another revision checked out there. It takes effect on the next load.
+func (e *EnvProf) SetCheckout(worktree, checkout string) {
+	e.worktree, e.checkout = worktree, checkout
+}
+
 // File returns the resolved file.
 func (e *EnvProf) File() file.File {
 	return e.file
@@ -69,7 +82,7 @@ func (e *EnvProf) TryParse(data []byte) error {
 	types := []Type{YAML, TOML}
 
 	for _, e.format = range types {
-		if _, err := Unmarshal(data, e.format); err == nil {
+		if _, _, err := Unmarshal(data, e.format); err == nil {
 			return nil
 		}
 	}
@@ -77,17 +90,26 @@ func (e *EnvProf) TryParse(data []byte) error {
 	return errors.New("format cannot be detected from content")
 }
 
-// GetOrDefault returns the profile name if it exists, or the default profile if none is specified.
+// GetOrDefault returns the profile name if specified, or the default profile otherwise (see Select).
 func (e *EnvProf) GetOrDefault(name string) (string, error) {
-	if name == "" {
-		name = e.profiles.Default()
-	}
+	selection, err := e.Select(name)
 
-	if name == "" {
-		return "", errors.New("no default profile found and none specified")
-	}
+	return selection.Name, err
+}
 
-	return name, nil
+// Format returns the format of the file, as determined by Type or TryParse.
+func (e *EnvProf) Format() Type {
+	return e.format
+}
+
+// Settings returns the loaded file-level settings.
+func (e *EnvProf) Settings() Settings {
+	return e.settings
+}
+
+// Hash returns the hex-encoded SHA-256 hash of the loaded content, before templating.
+func (e *EnvProf) Hash() string {
+	return e.hash
 }
 
 // Profiles returns the loaded profiles.
@@ -95,6 +117,23 @@ func (e *EnvProf) Profiles() profiles.Profiles {
 	return e.profiles
 }
 
+// Render renders the templates of the content with the environment,
+// along with ENVPROF_FILE and ENVPROF_DIR for the file.
+func (e *EnvProf) Render(data []byte) ([]byte, error) {
+	env := env.FromEnv()
+
+	// Add ENVPROF_FILE and ENVPROF_DIR for templating
+	_ = env.AddPair("ENVPROF_FILE", e.file.Path())
+	_ = env.AddPair("ENVPROF_DIR", filepath.ToSlash(e.file.Dir()))
+
+	data, err := Template(data, env)
+	if err != nil {
+		return nil, fmt.Errorf("templating profile file %q: %w", e.file.Path(), err)
+	}
+
+	return data, nil
+}
+
 // Load reads the file and unmarshals it into the store.
 func (e *EnvProf) Load() error {
 	data, err := e.file.Read()
@@ -102,15 +141,17 @@ func (e *EnvProf) Load() error {
 		return err
 	}
 
-	env := env.FromEnv()
+	return e.LoadBytes(data)
+}
 
-	// Add ENVPROF_FILE and ENVPROF_DIR for templating
-	_ = env.AddPair("ENVPROF_FILE", e.file.Path())
-	_ = env.AddPair("ENVPROF_DIR", filepath.ToSlash(e.file.Dir()))
+// LoadBytes unmarshals the given content into the store, as if it had been read from the file.
+func (e *EnvProf) LoadBytes(data []byte) error {
+	sum := sha256.Sum256(data)
+	e.hash = hex.EncodeToString(sum[:])
 
-	data, err = Template(data, env)
+	data, err := e.Render(data)
 	if err != nil {
-		return fmt.Errorf("templating profile file %q: %w", e.file.Path(), err)
+		return err
 	}
 
 	if errType := e.Type(); errType != nil {
@@ -123,16 +164,52 @@ func (e *EnvProf) Load() error {
 		}
 	}
 
-	profiles, err := Unmarshal(data, e.format)
+	profiles, settings, err := Unmarshal(data, e.format)
 	if err != nil {
 		return err
 	}
 
 	e.profiles = profiles
+	e.settings = settings
+
+	if err = settings.Validate(); err != nil {
+		return err
+	}
+
+	base, err := e.base(settings)
+	if err != nil {
+		return err
+	}
 
-	if err = profiles.Validate(); err != nil {
+	if err = profiles.Validate(base); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// base returns the directory relative dotenv paths are resolved against (see Settings.Base),
+// mapped into the checkout if set and within its working tree.
+func (e *EnvProf) base(settings Settings) (string, error) {
+	base, err := settings.Base(e.file.Dir())
+	if err != nil || e.checkout == "" {
+		return base, err
+	}
+
+	if base == "" {
+		if base, err = os.Getwd(); err != nil {
+			return "", err
+		}
+	}
+
+	if resolved, err := filepath.EvalSymlinks(base); err == nil {
+		base = resolved
+	}
+
+	// Directories outside the working tree are kept.
+	if rel, err := filepath.Rel(e.worktree, base); err == nil && filepath.IsLocal(rel) {
+		base = filepath.Join(e.checkout, rel)
+	}
+
+	return base, nil
+}
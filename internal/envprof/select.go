@@ -0,0 +1,150 @@
+package envprof
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/idelchi/envprof/internal/profile"
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// Variable is the environment variable selecting the profile when none is specified.
+const Variable = "ENVPROF_PROFILE"
+
+// Source is how the active profile was selected.
+type Source string
+
+const (
+	// Flag means the profile was specified explicitly.
+	Flag Source = "flag"
+	// Environment means the profile was selected through ENVPROF_PROFILE.
+	Environment Source = "environment"
+	// Rule means the profile's 'default_when' conditions matched the current context.
+	Rule Source = "rule"
+	// Default means the profile is marked with 'default: true'.
+	Default Source = "default"
+)
+
+// Selection is the active profile and how it was selected.
+type Selection struct {
+	// Name is the name of the selected profile.
+	Name string
+	// Source is how the profile was selected.
+	Source Source
+	// Reasons describe the matched conditions of a rule.
+	Reasons []string
+	// Others are further profiles whose rules matched, with fewer conditions or later names,
+	// or further profiles marked with 'default: true', with later names.
+	Others []string
+}
+
+// Explain describes why the profile was selected.
+func (s Selection) Explain() string {
+	var explanation string
+
+	switch s.Source {
+	case Flag:
+		explanation = "specified with --profile"
+	case Environment:
+		explanation = "specified with " + Variable
+	case Rule:
+		explanation = "default_when matched: " + strings.Join(s.Reasons, ", ")
+	case Default:
+		explanation = "marked with 'default: true'"
+	}
+
+	switch {
+	case len(s.Others) == 0:
+	case s.Source == Default:
+		explanation += fmt.Sprintf(" and first by name (also marked: %s)", strings.Join(s.Others, ", "))
+	default:
+		explanation += fmt.Sprintf(" (also matched: %s)", strings.Join(s.Others, ", "))
+	}
+
+	return fmt.Sprintf("profile %q selected: %s", s.Name, explanation)
+}
+
+// Select selects the active profile, in order of precedence:
+// the given name, ENVPROF_PROFILE, the first matching 'default_when' rule
+// and the first profile by name marked with 'default: true'.
+func (e *EnvProf) Select(name string) (Selection, error) {
+	return e.selectProfile(name, true)
+}
+
+// SelectStatic selects the profile like Select, but ignores the 'default_when' rules,
+// so that the selection does not depend on the current context.
+func (e *EnvProf) SelectStatic(name string) (Selection, error) {
+	return e.selectProfile(name, false)
+}
+
+// selectProfile selects the profile, considering the 'default_when' rules if rules is true.
+func (e *EnvProf) selectProfile(name string, rules bool) (Selection, error) {
+	if name != "" {
+		return Selection{Name: name, Source: Flag}, nil
+	}
+
+	if name := os.Getenv(Variable); name != "" {
+		return Selection{Name: name, Source: Environment}, nil
+	}
+
+	if rules && e.profiles.HasRules(false) {
+		rules := e.profiles.Rules(e.Context())
+
+		if len(rules) > 0 {
+			selection := Selection{Name: rules[0].Name, Source: Rule, Reasons: rules[0].Reasons}
+
+			for _, rule := range rules[1:] {
+				selection.Others = append(selection.Others, rule.Name)
+			}
+
+			return selection, nil
+		}
+	}
+
+	if defaults := e.profiles.Defaults(); len(defaults) > 0 {
+		return Selection{Name: defaults[0], Source: Default, Others: defaults[1:]}, nil
+	}
+
+	return Selection{}, errors.New("no default profile found and none specified")
+}
+
+// Context returns the current context for matching 'default_when' rules.
+// The git branch is only looked up if any rule depends on it.
+func (e *EnvProf) Context() profile.Context {
+	ctx := profile.Context{
+		OS:  runtime.GOOS,
+		Env: env.FromEnv(),
+	}
+
+	ctx.Hostname, _ = os.Hostname()
+
+	if e.profiles.HasRules(true) {
+		ctx.Branch = branch(e.file.Dir())
+	}
+
+	return ctx
+}
+
+// branch returns the current git branch of the directory, or the empty string if unknown.
+func branch(dir string) string {
+	var stdout bytes.Buffer
+
+	cmd := exec.CommandContext(context.Background(), "git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	if branch := strings.TrimSpace(stdout.String()); branch != "HEAD" {
+		return branch
+	}
+
+	return ""
+}
@@ -0,0 +1,86 @@
+package envprof
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Reserved is the top-level key holding the file-level settings instead of a profile.
+const Reserved = "envprof"
+
+// Relative selects the base against which relative dotenv paths are resolved.
+type Relative string
+
+const (
+	// Config resolves relative dotenv paths against the directory of the configuration file,
+	// as set in newly created files.
+	Config Relative = "config"
+	// CWD resolves relative dotenv paths against the current working directory, the default if not set.
+	CWD Relative = "cwd"
+)
+
+// Settings holds the file-level settings of a profile file.
+type Settings struct {
+	// RelativeTo selects how relative dotenv paths are resolved.
+	RelativeTo Relative `toml:"relative_to,omitempty" yaml:"relative_to,omitempty"`
+	// Audit is the path of the audit log, disabled if empty.
+	Audit string `toml:"audit,omitempty" yaml:"audit,omitempty"`
+}
+
+// Validate checks that the settings are valid.
+func (s Settings) Validate() error {
+	switch s.RelativeTo {
+	case "", Config, CWD:
+		return nil
+	default:
+		return fmt.Errorf(
+			"%s: relative_to: unsupported value %q, must be one of %q or %q",
+			Reserved,
+			s.RelativeTo,
+			Config,
+			CWD,
+		)
+	}
+}
+
+// Base returns the directory relative dotenv paths are resolved against,
+// given the directory of the configuration file.
+// An empty string means the current working directory, which is kept as the default for existing files,
+// unless relative_to is set to config.
+func (s Settings) Base(dir string) (string, error) {
+	if s.RelativeTo != Config {
+		return "", nil
+	}
+
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolving directory of %q: %w", dir, err)
+	}
+
+	return base, nil
+}
+
+// AuditLog returns the path of the audit log, or an empty string if disabled,
+// given the directory of the configuration file.
+// A leading "~" is expanded to the home directory, and relative paths are resolved against dir.
+func (s Settings) AuditLog(dir string) (string, error) {
+	path := s.Audit
+
+	switch {
+	case path == "":
+		return "", nil
+	case path == "~" || strings.HasPrefix(path, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("%s: audit: %w", Reserved, err)
+		}
+
+		path = filepath.Join(home, path[1:])
+	case !filepath.IsAbs(path):
+		path = filepath.Join(dir, path)
+	}
+
+	return filepath.Abs(path)
+}
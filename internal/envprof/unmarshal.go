@@ -6,36 +6,98 @@ import (
 
 	"github.com/BurntSushi/toml"
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
 
+	"github.com/idelchi/envprof/internal/profile"
 	"github.com/idelchi/envprof/internal/profiles"
 )
 
-// Unmarshal decodes the data into profiles.
-func Unmarshal(data []byte, format Type) (profiles profiles.Profiles, err error) {
+// Unmarshal decodes the data into profiles and the file-level settings.
+func Unmarshal(data []byte, format Type) (profiles profiles.Profiles, settings Settings, err error) {
 	switch format {
 	case YAML:
-		if err := yaml.UnmarshalWithOptions(data, &profiles, yaml.Strict()); err != nil {
-			return profiles, err
+		return unmarshalYAML(data)
+	case TOML:
+		return unmarshalTOML(data)
+	default:
+		return nil, settings, fmt.Errorf("unsupported file format: %q", format)
+	}
+}
+
+// unmarshalYAML decodes YAML data, separating the reserved settings key from the profiles.
+func unmarshalYAML(data []byte) (profiles.Profiles, Settings, error) {
+	var (
+		nodes    map[string]ast.Node
+		settings Settings
+	)
+
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, settings, err
+	}
+
+	out := make(profiles.Profiles, len(nodes))
+
+	for name, node := range nodes {
+		if name == Reserved {
+			if err := yaml.NodeToValue(node, &settings, yaml.Strict()); err != nil {
+				return nil, settings, err
+			}
+
+			continue
 		}
 
-	case TOML:
-		md, err := toml.Decode(string(data), &profiles)
-		if err != nil {
-			return profiles, err
+		var prof profile.Profile
+
+		if err := yaml.NodeToValue(node, &prof, yaml.Strict()); err != nil {
+			return nil, settings, err
 		}
 
-		if undecoded := md.Undecoded(); len(undecoded) > 0 {
-			errs := make([]error, len(undecoded))
-			for i, key := range undecoded {
-				errs[i] = fmt.Errorf("unknown field: %s", key.String())
+		out[name] = prof
+	}
+
+	return out, settings, nil
+}
+
+// unmarshalTOML decodes TOML data, separating the reserved settings key from the profiles.
+func unmarshalTOML(data []byte) (profiles.Profiles, Settings, error) {
+	var (
+		primitives map[string]toml.Primitive
+		settings   Settings
+	)
+
+	md, err := toml.Decode(string(data), &primitives)
+	if err != nil {
+		return nil, settings, err
+	}
+
+	out := make(profiles.Profiles, len(primitives))
+
+	for name, primitive := range primitives {
+		if name == Reserved {
+			if err := md.PrimitiveDecode(primitive, &settings); err != nil {
+				return nil, settings, err
 			}
 
-			return nil, errors.Join(errs...)
+			continue
 		}
 
-	default:
-		return nil, fmt.Errorf("unsupported file format: %q", format)
+		var prof profile.Profile
+
+		if err := md.PrimitiveDecode(primitive, &prof); err != nil {
+			return nil, settings, err
+		}
+
+		out[name] = prof
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		errs := make([]error, len(undecoded))
+		for i, key := range undecoded {
+			errs[i] = fmt.Errorf("unknown field: %s", key.String())
+		}
+
+		return nil, settings, errors.Join(errs...)
 	}
 
-	return profiles, nil
+	return out, settings, nil
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
@@ -65,29 +66,71 @@ func (es *Extends) Valid() error {
 }
 
 // Resolve expands glob patterns in dotenv extends and resolves all entries.
-func (es *Extends) Resolve() error {
-	var extends Extends
+// Relative dotenv paths are joined onto base, unless base is empty. Only the paths themselves are patterns,
+// glob metacharacters in base match literally.
+// Patterns without matches are kept as written and returned as unresolved, paths to missing files are resolved
+// nonetheless, and both are reported in the error.
+func (es *Extends) Resolve(base string) (unresolved Extends, err error) {
+	var (
+		extends Extends
+		errs    []error
+	)
 
 	for _, extend := range *es {
-		if extend.Type() == DotEnv {
-			path := extend.Path()
+		if extend.Type() != DotEnv {
+			extends = append(extends, extend)
 
-			matches, err := filepath.Glob(path)
-			if err != nil {
-				matches = []string{path}
-			}
+			continue
+		}
+
+		path, pattern := extend.Path(), extend.Path()
+
+		if base != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+			pattern = filepath.Join(escapeGlob(base), pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			matches = []string{path}
+		}
+
+		if len(matches) == 0 {
+			errs = append(errs, fmt.Errorf("dotenv %q: no matches found", path))
 
-			if len(matches) == 0 {
-				return fmt.Errorf("dotenv %q: no matches found", path)
+			if escapeGlob(extend.Path()) != extend.Path() {
+				unresolved = append(unresolved, extend)
+				extends = append(extends, extend)
+
+				continue
 			}
 
-			extends = append(extends, ToType(matches, extend.Type())...)
-		} else {
-			extends = append(extends, extend)
+			matches = []string{path}
 		}
+
+		extends = append(extends, ToType(matches, extend.Type())...)
 	}
 
 	*es = extends
 
-	return nil
+	return unresolved, errors.Join(errs...)
+}
+
+// escapeGlob escapes the glob metacharacters in path as single-character classes, so that it only matches itself.
+// Backslashes are escaped as well, except on Windows where they separate paths.
+func escapeGlob(path string) string {
+	var builder strings.Builder
+
+	for _, r := range path {
+		switch {
+		case r == '*', r == '?', r == '[':
+			builder.WriteString("[" + string(r) + "]")
+		case r == '\\' && runtime.GOOS != "windows":
+			builder.WriteString(`[\\]`)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
 }
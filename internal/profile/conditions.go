@@ -0,0 +1,112 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// Conditions select a profile as the default when all of the set conditions match the current context.
+// Values are glob patterns, matched as by path.Match.
+type Conditions struct {
+	// Hostname matches the name of the host.
+	Hostname string `toml:"hostname,omitempty" yaml:"hostname,omitempty"`
+	// OS matches the operating system, as reported by Go (e.g. "linux", "darwin", "windows").
+	OS string `toml:"os,omitempty" yaml:"os,omitempty"`
+	// Branch matches the current git branch of the directory of the configuration file.
+	Branch string `toml:"branch,omitempty" yaml:"branch,omitempty"`
+	// Env matches the values of environment variables, which must be set.
+	Env map[string]string `toml:"env,omitempty" yaml:"env,omitempty"`
+}
+
+// Context is the current context that conditions are matched against.
+type Context struct {
+	// Hostname is the name of the host.
+	Hostname string
+	// OS is the operating system.
+	OS string
+	// Branch is the current git branch, empty if unknown.
+	Branch string
+	// Env is the environment of the current process.
+	Env env.Env
+}
+
+// Count returns the number of set conditions.
+func (c Conditions) Count() int {
+	count := len(c.Env)
+
+	for _, pattern := range []string{c.Hostname, c.OS, c.Branch} {
+		if pattern != "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Validate checks that at least one condition is set and that all patterns are valid.
+func (c Conditions) Validate() error {
+	if c.Count() == 0 {
+		return errors.New("default_when: at least one of hostname, os, branch or env is required")
+	}
+
+	patterns := map[string]string{"hostname": c.Hostname, "os": c.OS, "branch": c.Branch}
+
+	for key, pattern := range c.Env {
+		patterns["env."+key] = pattern
+	}
+
+	for name, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("default_when: %s: invalid pattern %q: %w", name, pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Match reports whether all conditions match the context,
+// with a description of each matched condition.
+func (c Conditions) Match(ctx Context) (bool, []string) {
+	var reasons []string
+
+	match := func(name, pattern, value string) bool {
+		if pattern == "" {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, value); !ok {
+			return false
+		}
+
+		reasons = append(reasons, fmt.Sprintf("%s %s matches %s", name, strconv.Quote(value), strconv.Quote(pattern)))
+
+		return true
+	}
+
+	if !match("hostname", c.Hostname, ctx.Hostname) ||
+		!match("os", c.OS, ctx.OS) ||
+		(c.Branch != "" && ctx.Branch == "") ||
+		!match("branch", c.Branch, ctx.Branch) {
+		return false, nil
+	}
+
+	keys := make([]string, 0, len(c.Env))
+	for key := range c.Env {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if !ctx.Env.Exists(key) || !match("$"+key, c.Env[key], ctx.Env.Get(key)) {
+			return false, nil
+		}
+	}
+
+	return true, reasons
+}
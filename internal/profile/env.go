@@ -52,7 +52,7 @@ func (e *Env) FromEnv(env env.Env) {
 
 // Stringified serializes Env into env.Env using Stringify.
 // – Scalars pass through unchanged.
-// – Non-scalars are JSON-minified and single-quoted (see Stringify).
+// – Non-scalars are JSON-minified (see Stringify).
 func (e *Env) Stringified() (env.Env, error) {
 	env := make(env.Env, len(*e))
 
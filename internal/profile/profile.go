@@ -1,8 +1,14 @@
 package profile
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/idelchi/envprof/internal/environment"
 	"github.com/idelchi/envprof/internal/extends"
+	"github.com/idelchi/envprof/internal/secret"
 )
 
 // Profile represents a configuration profile with environment variables and metadata.
@@ -13,20 +19,88 @@ type Profile struct {
 	Extends extends.Extends `toml:"extends,omitempty" yaml:"extends,omitempty"`
 	// Output is the desired output file.
 	Output string `toml:"output,omitempty" yaml:"output,omitempty"`
+	// OutputFormat is the format of the output file, inferred from its extension if empty.
+	OutputFormat string `toml:"output_format,omitempty" yaml:"output_format,omitempty"`
+	// Mode is the octal file mode of the output file (e.g. "0600").
+	Mode string `toml:"mode,omitempty" yaml:"mode,omitempty"`
+	// Color is the color of the profile in shell prompts (e.g. "red" for production).
+	Color string `toml:"color,omitempty" yaml:"color,omitempty"`
+	// Shell customizes shells spawned with the 'shell' command.
+	Shell *Shell `toml:"shell,omitempty" yaml:"shell,omitempty"`
+	// Pre are shell commands run by 'exec' before the command, aborting if any fails.
+	Pre []string `toml:"pre,omitempty" yaml:"pre,omitempty"`
+	// Post are shell commands run by 'exec' after the command, regardless of its outcome.
+	Post []string `toml:"post,omitempty" yaml:"post,omitempty"`
+	// Protected requires confirmation before the profile, or any profile extending it, is used.
+	Protected bool `toml:"protected,omitempty" yaml:"protected,omitempty"`
+	// ProtectedMessage is shown when asking for confirmation of a protected profile.
+	ProtectedMessage string `toml:"protected_message,omitempty" yaml:"protected_message,omitempty"`
+	// TTL limits how long the profile stays active in 'shell' and 'exec' (e.g. "30m").
+	TTL string `toml:"ttl,omitempty" yaml:"ttl,omitempty"`
 	// Default indicates whether this profile is the default one.
 	Default bool `toml:"default,omitempty" yaml:"default,omitempty"`
+	// DefaultWhen makes this profile the default when the conditions match the current context.
+	DefaultWhen *Conditions `toml:"default_when,omitempty" yaml:"default_when,omitempty"`
+
+	// Unresolved are the dotenv extends whose patterns had no matches, kept as written (set by validation).
+	Unresolved extends.Extends `toml:"-" yaml:"-"`
+}
+
+// FileMode parses the file mode of the output file, returning 0 if not set.
+func (p *Profile) FileMode() (os.FileMode, error) {
+	if p.Mode == "" {
+		return 0, nil
+	}
+
+	return ParseMode(p.Mode)
+}
+
+// ParseMode parses an octal file mode such as "0600".
+func ParseMode(mode string) (os.FileMode, error) {
+	//nolint:mnd	// Octal, fitting into a file mode.
+	parsed, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || parsed > uint64(os.ModePerm) {
+		return 0, fmt.Errorf("invalid file mode %q, must be octal permissions such as \"0600\"", mode)
+	}
+
+	return os.FileMode(parsed), nil
+}
+
+// Duration parses the time-to-live of the profile, returning 0 if not set.
+func (p *Profile) Duration() (time.Duration, error) {
+	if p.TTL == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(p.TTL)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid ttl %q, must be a positive duration such as \"30m\"", p.TTL)
+	}
+
+	return ttl, nil
 }
 
 // ToEnv converts the profile to an environment representation,
-// stringifying the environment variables.
+// stringifying the environment variables and decrypting encrypted values.
 func (p *Profile) ToEnv(name string) (environment.Environment, error) {
 	stringified, err := p.Env.Stringified()
 	if err != nil {
 		return environment.Environment{}, err
 	}
 
+	sensitive := false
+
+	for _, key := range stringified.Keys() {
+		sensitive = sensitive || secret.IsEncrypted(stringified[key])
+
+		if stringified[key], err = secret.Decrypt(name, key, stringified[key]); err != nil {
+			return environment.Environment{}, fmt.Errorf("env %q: %w", key, err)
+		}
+	}
+
 	return environment.Environment{
-		Name: name,
-		Env:  stringified,
+		Name:      name,
+		Env:       stringified,
+		Sensitive: sensitive,
 	}, nil
 }
@@ -0,0 +1,28 @@
+package profile
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// aliasName matches the names allowed for aliases in all supported shells.
+var aliasName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// Shell customizes the shells spawned for the profile.
+type Shell struct {
+	// Init are commands run at startup of the shell, after the user's own startup files.
+	Init []string `toml:"init,omitempty" yaml:"init,omitempty"`
+	// Aliases maps alias names to the commands they expand to.
+	Aliases map[string]string `toml:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// Validate checks that the alias names are valid.
+func (s Shell) Validate() error {
+	for name := range s.Aliases {
+		if !aliasName.MatchString(name) {
+			return fmt.Errorf("shell.aliases: invalid alias name %q", name)
+		}
+	}
+
+	return nil
+}
@@ -4,24 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"strings"
-	"unicode"
 )
 
-// Stringify turns any Go value into the dotenv-ready string described earlier.
+// Stringify turns any Go value into the plain string value of an environment variable.
 //   - Scalars → plain strings (`true`, `5432`, `foo`…).
-//   - Slices / maps / structs → compact JSON wrapped in single quotes
-//     (`'["a","b"]'`, `'{"k":"v"}'`).
+//   - Slices / maps / structs → compact JSON (`["a","b"]`, `{"k":"v"}`).
+//
+// Quoting is left to the writers (see dotenv.Quote).
 func Stringify(v any) (string, error) {
 	switch val := v.(type) {
 	case nil:
 		return "", nil
 
 	case string:
-		if needsQuotes(val) {
-			return strconv.Quote(val), nil // adds double-quotes and escapes
-		}
-
 		return val, nil
 
 	case bool:
@@ -47,25 +42,6 @@ func Stringify(v any) (string, error) {
 			return "", fmt.Errorf("json: %w", err)
 		}
 
-		return "'" + string(raw) + "'", nil
-	}
-}
-
-// needsQuotes returns true if the string contains characters that
-// require quoting in a dotenv file (space, =, #, quotes, etc.).
-func needsQuotes(s string) bool {
-	if s == "" {
-		return false
+		return string(raw), nil
 	}
-
-	for _, r := range s {
-		switch {
-		case unicode.IsSpace(r):
-			return true
-		case strings.ContainsRune(`"'#=`, r):
-			return true
-		}
-	}
-
-	return false
 }
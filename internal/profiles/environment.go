@@ -14,7 +14,11 @@ func (p Profiles) Environment(name string, steps step.Steps) (environment.Enviro
 		return environment.Environment{}, err
 	}
 
-	out := environment.New(name, cur.Output)
+	out := environment.New(name, cur.Output, environment.Format(cur.OutputFormat))
+
+	if out.Mode, err = cur.FileMode(); err != nil {
+		return out, fmt.Errorf("profile %q: %w", name, err)
+	}
 
 	for _, stp := range steps {
 		switch stp.Kind {
@@ -54,7 +58,7 @@ func (p Profiles) Environment(name string, steps step.Steps) (environment.Enviro
 
 // Environments returns a fully resolved list of environments for all profiles.
 func (p Profiles) Environments() (environments []environment.Environment, err error) {
-	for name := range p {
+	for _, name := range p.Names() {
 		steps, err := p.Plan(name)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,417 @@
+package profiles
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/idelchi/envprof/internal/extends"
+)
+
+// Node is a profile or dotenv file in the extends graph.
+type Node struct {
+	// ID identifies the node, the profile name or the prefixed extend entry for other kinds.
+	ID string
+	// Kind is the type of the node.
+	Kind extends.Extend
+	// Label is the profile name or dotenv path.
+	Label string
+	// Default marks the default profile.
+	Default bool
+	// Missing marks references to profiles or dotenv files that do not exist, and unsupported extends.
+	Missing bool
+	// Unresolved marks dotenv patterns without matches, which are shown as written rather than checked.
+	Unresolved bool
+	// Unreachable marks profiles not used by any profile selectable by default,
+	// through 'default' or 'default_when'. Only set if such a profile exists.
+	Unreachable bool
+}
+
+// Edge is an extends relationship, from the extending profile to the extended node.
+type Edge struct {
+	// From is the ID of the extending profile.
+	From string
+	// To is the ID of the extended node.
+	To string
+	// Cycle marks edges closing a cycle.
+	Cycle bool
+}
+
+// Graph is the extends graph of all profiles.
+type Graph struct {
+	// Nodes are the profiles followed by the other referenced nodes, each sorted by ID.
+	Nodes []Node
+	// Edges are the extends relationships, in the order of the extends entries.
+	Edges []Edge
+}
+
+// Graph builds the extends graph of all profiles.
+func (p Profiles) Graph() Graph {
+	var graph Graph
+
+	index := map[string]int{}
+
+	add := func(node Node) {
+		if _, ok := index[node.ID]; !ok {
+			index[node.ID] = len(graph.Nodes)
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+
+	defaults := p.Defaults()
+
+	for _, name := range p.Names() {
+		add(Node{ID: name, Kind: extends.Profile, Label: name, Default: slices.Contains(defaults, name)})
+	}
+
+	var others []Node
+
+	for _, name := range p.Names() {
+		for _, extend := range p[name].Extends {
+			node := Node{ID: extend.Path(), Kind: extend.Type(), Label: extend.Path()}
+
+			switch node.Kind {
+			case extends.Profile:
+				node.Missing = !p.Exists(node.ID)
+			case extends.DotEnv:
+				node.ID = string(extends.DotEnv) + ":" + node.Label
+
+				if slices.Contains(p[name].Unresolved, extend) {
+					node.Unresolved = true
+
+					break
+				}
+
+				_, err := os.Stat(node.Label)
+				node.Missing = err != nil
+			default:
+				node.ID = string(extend)
+				node.Missing = true
+			}
+
+			if !p.Exists(node.ID) {
+				others = append(others, node)
+			}
+
+			graph.Edges = append(graph.Edges, Edge{From: name, To: node.ID})
+		}
+	}
+
+	slices.SortStableFunc(others, func(x, y Node) int { return strings.Compare(x.ID, y.ID) })
+
+	for _, node := range others {
+		add(node)
+	}
+
+	graph.markCycles()
+
+	roots := slices.Clone(defaults)
+
+	for _, name := range p.Names() {
+		if p[name].DefaultWhen != nil && !slices.Contains(roots, name) {
+			roots = append(roots, name)
+		}
+	}
+
+	if len(roots) > 0 {
+		reachable := graph.reachable(roots...)
+
+		for i, node := range graph.Nodes {
+			graph.Nodes[i].Unreachable = node.Kind == extends.Profile && !node.Missing && !reachable[node.ID]
+		}
+	}
+
+	return graph
+}
+
+// children returns the indices of the edges starting at the given node.
+func (g Graph) children(id string) (edges []int) {
+	for i, edge := range g.Edges {
+		if edge.From == id {
+			edges = append(edges, i)
+		}
+	}
+
+	return edges
+}
+
+// markCycles marks the edges closing a cycle, following the same depth-first visit as the plan.
+func (g *Graph) markCycles() {
+	type state uint8
+
+	const (
+		visiting state = 1
+		visited  state = 2
+	)
+
+	seen := map[string]state{}
+
+	var visit func(string)
+
+	visit = func(node string) {
+		seen[node] = visiting
+
+		for _, i := range g.children(node) {
+			switch seen[g.Edges[i].To] {
+			case visiting:
+				g.Edges[i].Cycle = true
+			case 0:
+				visit(g.Edges[i].To)
+			}
+		}
+
+		seen[node] = visited
+	}
+
+	for _, node := range g.Nodes {
+		if seen[node.ID] == 0 {
+			visit(node.ID)
+		}
+	}
+}
+
+// reachable returns the IDs of the nodes reachable from the given roots, including the roots.
+func (g Graph) reachable(roots ...string) map[string]bool {
+	reached := map[string]bool{}
+
+	var visit func(string)
+
+	visit = func(node string) {
+		if reached[node] {
+			return
+		}
+
+		reached[node] = true
+
+		for _, i := range g.children(node) {
+			visit(g.Edges[i].To)
+		}
+	}
+
+	for _, root := range roots {
+		visit(root)
+	}
+
+	return reached
+}
+
+// node returns the node with the given ID.
+func (g Graph) node(id string) Node {
+	for _, node := range g.Nodes {
+		if node.ID == id {
+			return node
+		}
+	}
+
+	return Node{ID: id, Label: id}
+}
+
+// markers returns the annotations of a node, such as "default" or "missing".
+func (n Node) markers() (markers []string) {
+	if n.Default {
+		markers = append(markers, "default")
+	}
+
+	if n.Missing {
+		markers = append(markers, "missing")
+	}
+
+	if n.Unresolved {
+		markers = append(markers, "unresolved")
+	}
+
+	if n.Unreachable {
+		markers = append(markers, "unreachable")
+	}
+
+	return markers
+}
+
+// title returns the label of a node, prefixed with its kind unless it is a profile.
+func (n Node) title() string {
+	if n.Kind == extends.Profile {
+		return n.Label
+	}
+
+	return string(n.Kind) + ":" + n.Label
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+func (g Graph) DOT() string {
+	var builder strings.Builder
+
+	builder.WriteString("digraph envprof {\n")
+	builder.WriteString("  rankdir=BT;\n")
+	builder.WriteString("  node [shape=box];\n")
+
+	for _, node := range g.Nodes {
+		label := node.title()
+
+		if markers := node.markers(); len(markers) > 0 {
+			label += "\n(" + strings.Join(markers, ", ") + ")"
+		}
+
+		attributes := []string{"label=" + strconv.Quote(label)}
+
+		if node.Kind == extends.DotEnv {
+			attributes = append(attributes, "shape=note")
+		}
+
+		switch {
+		case node.Missing:
+			attributes = append(attributes, "style=dashed", "color=red", "fontcolor=red")
+		case node.Unresolved:
+			attributes = append(attributes, "style=dashed", "color=orange", "fontcolor=orange")
+		case node.Default:
+			attributes = append(attributes, "style=bold", "peripheries=2")
+		case node.Unreachable:
+			attributes = append(attributes, "color=gray", "fontcolor=gray")
+		}
+
+		fmt.Fprintf(&builder, "  %s [%s];\n", strconv.Quote(node.ID), strings.Join(attributes, ", "))
+	}
+
+	for _, edge := range g.Edges {
+		attributes := ""
+		if edge.Cycle {
+			attributes = ` [color=red, label="cycle"]`
+		}
+
+		fmt.Fprintf(&builder, "  %s -> %s%s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To), attributes)
+	}
+
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g Graph) Mermaid() string {
+	var builder strings.Builder
+
+	ids := map[string]string{}
+
+	for i, node := range g.Nodes {
+		ids[node.ID] = "n" + strconv.Itoa(i)
+	}
+
+	builder.WriteString("flowchart BT\n")
+
+	for _, node := range g.Nodes {
+		label := node.title()
+
+		if markers := node.markers(); len(markers) > 0 {
+			label += "<br>(" + strings.Join(markers, ", ") + ")"
+		}
+
+		label = strings.ReplaceAll(label, `"`, "#quot;")
+
+		if node.Kind == extends.DotEnv {
+			fmt.Fprintf(&builder, "  %s[/\"%s\"/]\n", ids[node.ID], label)
+		} else {
+			fmt.Fprintf(&builder, "  %s[\"%s\"]\n", ids[node.ID], label)
+		}
+	}
+
+	for _, edge := range g.Edges {
+		if edge.Cycle {
+			fmt.Fprintf(&builder, "  %s -. cycle .-> %s\n", ids[edge.From], ids[edge.To])
+		} else {
+			fmt.Fprintf(&builder, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+		}
+	}
+
+	builder.WriteString("  classDef primary stroke-width:3px\n")
+	builder.WriteString("  classDef missing stroke:#d00,stroke-dasharray:5 5,color:#d00\n")
+	builder.WriteString("  classDef unresolved stroke:#e80,stroke-dasharray:5 5,color:#e80\n")
+	builder.WriteString("  classDef unreachable stroke:#999,color:#999\n")
+
+	for _, node := range g.Nodes {
+		switch {
+		case node.Missing:
+			fmt.Fprintf(&builder, "  class %s missing\n", ids[node.ID])
+		case node.Unresolved:
+			fmt.Fprintf(&builder, "  class %s unresolved\n", ids[node.ID])
+		case node.Default:
+			fmt.Fprintf(&builder, "  class %s primary\n", ids[node.ID])
+		case node.Unreachable:
+			fmt.Fprintf(&builder, "  class %s unreachable\n", ids[node.ID])
+		}
+	}
+
+	return builder.String()
+}
+
+// Tree renders the graph as an ASCII tree, starting from the profiles that no other profile extends.
+// Profiles extended by several others are repeated under each of them.
+func (g Graph) Tree() string {
+	var builder strings.Builder
+
+	extended := map[string]bool{}
+
+	for _, edge := range g.Edges {
+		extended[edge.To] = true
+	}
+
+	printed := map[string]bool{}
+
+	var visit func(id, prefix string)
+
+	visit = func(id, prefix string) {
+		printed[id] = true
+
+		children := g.children(id)
+
+		for n, i := range children {
+			edge := g.Edges[i]
+			node := g.node(edge.To)
+
+			branch, indent := "├── ", "│   "
+			if n == len(children)-1 {
+				branch, indent = "└── ", "    "
+			}
+
+			markers := node.markers()
+			if edge.Cycle {
+				markers = append(markers, "cycle")
+			}
+
+			builder.WriteString(prefix + branch + node.title() + suffix(markers) + "\n")
+
+			if !edge.Cycle {
+				visit(node.ID, prefix+indent)
+			}
+		}
+	}
+
+	root := func(node Node) {
+		builder.WriteString(node.title() + suffix(node.markers()) + "\n")
+		visit(node.ID, "")
+	}
+
+	for _, node := range g.Nodes {
+		if node.Kind == extends.Profile && !node.Missing && !extended[node.ID] {
+			root(node)
+		}
+	}
+
+	// Profiles that are only part of cycles have no root above them.
+	for _, node := range g.Nodes {
+		if node.Kind == extends.Profile && !node.Missing && !printed[node.ID] {
+			root(node)
+		}
+	}
+
+	return builder.String()
+}
+
+// suffix formats markers as a parenthesized suffix, or the empty string if there are none.
+func suffix(markers []string) string {
+	if len(markers) == 0 {
+		return ""
+	}
+
+	return " (" + strings.Join(markers, ", ") + ")"
+}
@@ -5,8 +5,14 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+	"strings"
 
+	"github.com/goccy/go-yaml"
+
+	"github.com/idelchi/envprof/internal/environment"
+	"github.com/idelchi/envprof/internal/extends"
 	"github.com/idelchi/envprof/internal/profile"
+	"github.com/idelchi/envprof/pkg/terminal"
 )
 
 // ErrValidation is returned when profiles validation fails.
@@ -31,10 +37,10 @@ func (p Profiles) Names() []string {
 	return names
 }
 
-// Defaults returns the names of the default profiles.
+// Defaults returns the names of the profiles marked with 'default: true', in sorted order.
 func (p Profiles) Defaults() (defaults []string) {
-	for name, profile := range p {
-		if profile.Default {
+	for _, name := range p.Names() {
+		if p[name].Default {
 			defaults = append(defaults, name)
 		}
 	}
@@ -42,7 +48,20 @@ func (p Profiles) Defaults() (defaults []string) {
 	return defaults
 }
 
-// Default returns the name of the first default profile found.
+// Dependents returns the names of the profiles extending the given profile directly, in sorted order.
+func (p Profiles) Dependents(name string) (dependents []string) {
+	for _, candidate := range p.Names() {
+		if slices.ContainsFunc(p[candidate].Extends, func(extend extends.Extend) bool {
+			return extend.Type() == extends.Profile && extend.Path() == name
+		}) {
+			dependents = append(dependents, candidate)
+		}
+	}
+
+	return dependents
+}
+
+// Default returns the name of the first profile by name marked with 'default: true'.
 func (p Profiles) Default() string {
 	defaults := p.Defaults()
 	if len(defaults) == 0 {
@@ -52,24 +71,60 @@ func (p Profiles) Default() string {
 	return defaults[0]
 }
 
-// Validate checks that the profiles are valid.
-func (p Profiles) Validate() error {
+// Validate checks that the profiles are valid and resolves their dotenv extends.
+// All problems are reported at once, including every cycle and reference to a missing profile.
+// Relative dotenv paths are resolved against base, or the current working directory if base is empty.
+func (p Profiles) Validate(base string) error {
 	var errs []error
 
-	defaults := p.Defaults()
+	for _, name := range p.Names() {
+		profile := p[name]
 
-	if len(defaults) > 1 {
-		errs = append(errs, fmt.Errorf("%w: more than one default profile: %v", ErrValidation, defaults))
-	}
+		// Lists of profiles, such as ENVPROF_PROFILE_STACK and the --overlay flag, are comma-separated.
+		if strings.Contains(name, ",") {
+			errs = append(errs, fmt.Errorf("%w: profile %q: name must not contain ','", ErrValidation, name))
+		}
+
+		if err := environment.Format(profile.OutputFormat).Valid(); err != nil {
+			errs = append(errs, fmt.Errorf("%w: profile %q: %w", ErrValidation, name, err))
+		}
+
+		if profile.DefaultWhen != nil {
+			if err := profile.DefaultWhen.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%w: profile %q: %w", ErrValidation, name, err))
+			}
+		}
 
-	for name, profile := range p {
-		if err := profile.Extends.Resolve(); err != nil {
-			return fmt.Errorf("%w: profile %q: %w", ErrValidation, name, err)
+		if profile.Shell != nil {
+			if err := profile.Shell.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%w: profile %q: %w", ErrValidation, name, err))
+			}
 		}
 
+		if err := terminal.Color(profile.Color).Valid(); err != nil {
+			errs = append(errs, fmt.Errorf("%w: profile %q: %w", ErrValidation, name, err))
+		}
+
+		if _, err := profile.Duration(); err != nil {
+			errs = append(errs, fmt.Errorf("%w: profile %q: %w", ErrValidation, name, err))
+		}
+
+		if _, err := profile.FileMode(); err != nil {
+			errs = append(errs, fmt.Errorf("%w: profile %q: %w", ErrValidation, name, err))
+		}
+
+		unresolved, err := profile.Extends.Resolve(base)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: profile %q: %w", ErrValidation, name, err))
+		}
+
+		profile.Unresolved = unresolved
+
 		p[name] = profile
 	}
 
+	errs = append(errs, p.validateExtends()...)
+
 	return errors.Join(errs...)
 }
 
@@ -86,3 +141,67 @@ func (p Profiles) Get(name string) (profile.Profile, error) {
 
 	return p[name], nil
 }
+
+// Mismatch returns an error naming the first profile, by name, that differs between the profiles,
+// or nil if they define the same profiles.
+// Env values are compared as the strings they are exported as, and extends regardless of an explicit prefix,
+// so that a file can be compared with its reformatted or converted content.
+func (p Profiles) Mismatch(other Profiles) error {
+	names := append(p.Names(), other.Names()...)
+
+	slices.Sort(names)
+
+	names = slices.Compact(names)
+
+	for _, name := range names {
+		switch {
+		case !p.Exists(name):
+			return fmt.Errorf("profile %q was added", name)
+		case !other.Exists(name):
+			return fmt.Errorf("profile %q was removed", name)
+		}
+
+		first, err := canonical(p[name])
+		if err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+
+		second, err := canonical(other[name])
+		if err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+
+		if first != second {
+			return fmt.Errorf("profile %q differs", name)
+		}
+	}
+
+	return nil
+}
+
+// canonical returns the profile in a form for comparison, with stringified env values and prefixed extends.
+func canonical(prof profile.Profile) (string, error) {
+	env, err := prof.Env.Stringified()
+	if err != nil {
+		return "", err
+	}
+
+	prof.Env = make(profile.Env, len(env))
+
+	for key, value := range env {
+		prof.Env[key] = value
+	}
+
+	prof.Extends = slices.Clone(prof.Extends)
+
+	for i, extend := range prof.Extends {
+		prof.Extends[i] = extends.Extend(string(extend.Type()) + ":" + extend.Path())
+	}
+
+	data, err := yaml.Marshal(prof)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
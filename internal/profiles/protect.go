@@ -0,0 +1,96 @@
+package profiles
+
+import (
+	"time"
+
+	"github.com/idelchi/envprof/internal/step"
+)
+
+// Protection is a protected profile contributing to an environment.
+type Protection struct {
+	// Name is the name of the protected profile.
+	Name string
+	// Message explains the protection, if set.
+	Message string
+}
+
+// Protections returns the protected profiles contributing to the environment of the plan.
+func (p Profiles) Protections(steps step.Steps) ([]Protection, error) {
+	names, err := p.contributors(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	var protections []Protection
+
+	for _, name := range names {
+		if profile := p[name]; profile.Protected {
+			protections = append(protections, Protection{Name: name, Message: profile.ProtectedMessage})
+		}
+	}
+
+	return protections, nil
+}
+
+// TTL returns the shortest time-to-live among the profiles contributing to the environment of the plan,
+// or 0 if none of them has one.
+func (p Profiles) TTL(steps step.Steps) (time.Duration, error) {
+	names, err := p.contributors(steps)
+	if err != nil {
+		return 0, err
+	}
+
+	var ttl time.Duration
+
+	for _, name := range names {
+		profile := p[name]
+
+		duration, err := profile.Duration()
+		if err != nil {
+			return 0, err
+		}
+
+		if duration > 0 && (ttl == 0 || duration < ttl) {
+			ttl = duration
+		}
+	}
+
+	return ttl, nil
+}
+
+// contributors returns the names of the profiles contributing to the environment of the plan,
+// including the profiles it extends and the overlays along with the profiles they extend.
+func (p Profiles) contributors(steps step.Steps) ([]string, error) {
+	var names []string
+
+	seen := map[string]bool{}
+
+	var walk func(steps step.Steps) error
+
+	walk = func(steps step.Steps) error {
+		for _, stp := range steps {
+			switch stp.Kind {
+			case step.Profile:
+				if !seen[stp.Name] {
+					seen[stp.Name] = true
+
+					names = append(names, stp.Name)
+				}
+			case step.Overlay:
+				overlay, err := p.Plan(stp.Name)
+				if err != nil {
+					return err
+				}
+
+				if err := walk(overlay); err != nil {
+					return err
+				}
+			case step.DotEnv:
+			}
+		}
+
+		return nil
+	}
+
+	return names, walk(steps)
+}
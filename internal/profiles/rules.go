@@ -0,0 +1,51 @@
+package profiles
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/idelchi/envprof/internal/profile"
+)
+
+// Rule is a profile whose 'default_when' conditions match the current context.
+type Rule struct {
+	// Name is the name of the profile.
+	Name string
+	// Reasons describe the matched conditions.
+	Reasons []string
+}
+
+// HasRules reports whether any profile has 'default_when' conditions.
+// If branch is true, only conditions on the git branch are considered.
+func (p Profiles) HasRules(branch bool) bool {
+	for _, profile := range p {
+		if profile.DefaultWhen != nil && (!branch || profile.DefaultWhen.Branch != "") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Rules returns the profiles whose 'default_when' conditions match the context,
+// the most specific (most conditions) first, and then by name.
+func (p Profiles) Rules(ctx profile.Context) []Rule {
+	var rules []Rule
+
+	for _, name := range p.Names() {
+		conditions := p[name].DefaultWhen
+		if conditions == nil {
+			continue
+		}
+
+		if ok, reasons := conditions.Match(ctx); ok {
+			rules = append(rules, Rule{Name: name, Reasons: reasons})
+		}
+	}
+
+	slices.SortStableFunc(rules, func(x, y Rule) int {
+		return cmp.Compare(len(y.Reasons), len(x.Reasons))
+	})
+
+	return rules
+}
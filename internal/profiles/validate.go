@@ -0,0 +1,128 @@
+package profiles
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/idelchi/envprof/internal/extends"
+)
+
+// Cycles returns every cycle in the extends graph of the profiles, as the path of profile names
+// starting and ending with the same profile. Each cycle is reported once, starting from its smallest profile name.
+func (p Profiles) Cycles() (cycles [][]string) {
+	found := map[string]bool{}
+
+	for _, root := range p.Names() {
+		var visit func(path []string)
+
+		visit = func(path []string) {
+			for _, extend := range p[path[len(path)-1]].Extends {
+				child := extend.Path()
+
+				switch {
+				case extend.Type() != extends.Profile || !p.Exists(child):
+				case child == root:
+					cycle := append(slices.Clone(path), root)
+
+					if key := strings.Join(cycle, "\x00"); !found[key] {
+						found[key] = true
+
+						cycles = append(cycles, cycle)
+					}
+				// Only profiles after the root are visited, so that each cycle is found from its smallest profile.
+				case child > root && !slices.Contains(path, child):
+					visit(append(slices.Clone(path), child))
+				}
+			}
+		}
+
+		visit([]string{root})
+	}
+
+	return cycles
+}
+
+// Suggest returns the profile name closest to the given one, if it is a likely misspelling.
+func (p Profiles) Suggest(name string) (string, bool) {
+	best, distance := "", -1
+
+	for _, candidate := range p.Names() {
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(candidate)); distance < 0 || d < distance {
+			best, distance = candidate, d
+		}
+	}
+
+	//nolint:mnd	// Allow roughly one edit per three characters, and at least two.
+	if distance < 0 || distance > max(2, len(name)/3) {
+		return "", false
+	}
+
+	return best, true
+}
+
+// validateExtends checks the extends of all profiles,
+// reporting every cycle, reference to a missing profile and unsupported extends.
+func (p Profiles) validateExtends() (errs []error) {
+	for _, name := range p.Names() {
+		for _, extend := range p[name].Extends {
+			switch extend.Type() {
+			case extends.DotEnv:
+			case extends.Profile:
+				target := extend.Path()
+
+				if p.Exists(target) {
+					continue
+				}
+
+				err := fmt.Errorf("%w: profile %q: extends unknown profile %q", ErrValidation, name, target)
+
+				if suggestion, ok := p.Suggest(target); ok {
+					err = fmt.Errorf("%w, did you mean %q?", err, suggestion)
+				}
+
+				errs = append(errs, err)
+			default:
+				errs = append(errs, fmt.Errorf(
+					"%w: profile %q: unsupported extends %q, must be prefixed with %q or %q",
+					ErrValidation, name, extend, extends.Profile+":", extends.DotEnv+":",
+				))
+			}
+		}
+	}
+
+	for _, cycle := range p.Cycles() {
+		errs = append(errs, fmt.Errorf("%w: cycle detected: %s", ErrValidation, strings.Join(cycle, " -> ")))
+	}
+
+	return errs
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(first, second string) int {
+	a, b := []rune(first), []rune(second)
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := range a {
+		current[0] = i + 1
+
+		for j := range b {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+
+			current[j+1] = min(previous[j+1]+1, current[j]+1, previous[j]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,6 @@
+// Package secret encrypts and decrypts env values with a local key, using AES-256-GCM.
+// Encrypted values are stored as "enc:v1:" followed by the base64-encoded nonce and ciphertext,
+// so they can be committed along with the rest of the profile file.
+// The profile and key of a value are authenticated as additional data,
+// so an encrypted value cannot be moved to another profile or key.
+package secret
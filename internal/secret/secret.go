@@ -0,0 +1,206 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// Prefix marks encrypted values, versioned to make collisions with plain values unlikely.
+	// A plain value starting with it cannot be stored as is and must be encrypted instead.
+	Prefix = "enc:v1:"
+	// KeyVariable is the variable holding the base64-encoded key, taking precedence over the key file.
+	KeyVariable = "ENVPROF_KEY"
+	// KeyFileVariable is the variable holding the path of the key file.
+	KeyFileVariable = "ENVPROF_KEY_FILE"
+
+	// keySize is the size of the key in bytes, selecting AES-256.
+	keySize = 32
+)
+
+// ErrNoKey is returned when no key is configured.
+var ErrNoKey = errors.New("no key found")
+
+// Key is a key for encrypting and decrypting values.
+type Key []byte
+
+// IsEncrypted reports whether the value is encrypted.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// KeyFile returns the path of the key file, from ENVPROF_KEY_FILE or "~/.config/envprof/key".
+func KeyFile() (string, error) {
+	if path := os.Getenv(KeyFileVariable); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, ".config", "envprof", "key"), nil
+}
+
+// Load returns the key from ENVPROF_KEY or the key file.
+func Load() (Key, error) {
+	if encoded := os.Getenv(KeyVariable); encoded != "" {
+		key, err := parse(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", KeyVariable, err)
+		}
+
+		return key, nil
+	}
+
+	path, err := KeyFile()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: set %s or create the key file %q", ErrNoKey, KeyVariable, path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("key file %q: %w", path, err)
+	}
+
+	return key, nil
+}
+
+// loadOnce loads the key at most once per process.
+//
+//nolint:gochecknoglobals	// Caches the key across the profiles of a file.
+var loadOnce = sync.OnceValues(Load)
+
+// Generate creates a new random key and writes it to the key file, failing if it already exists.
+func Generate(path string) (Key, error) {
+	key := make(Key, keySize)
+
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	//nolint:mnd	// Private directory for the key.
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	//nolint:mnd	// Private key file.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return key, file.Close()
+}
+
+// parse decodes a base64-encoded key.
+func parse(encoded string) (Key, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("invalid key, must be %d base64-encoded bytes", keySize)
+	}
+
+	return key, nil
+}
+
+// Encrypt encrypts the value of the key in the profile, returning it with the prefix.
+// The value is bound to the profile and key, and only decrypts for the same pair.
+func (k Key) Encrypt(profile, name, value string) (string, error) {
+	aead, err := k.aead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), additional(profile, name))
+
+	return Prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts an encrypted value of the key in the profile.
+func (k Key) Decrypt(profile, name, value string) (string, error) {
+	aead, err := k.aead()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additional(profile, name))
+	if err != nil {
+		return "", errors.New("decryption failed, wrong key, corrupted value or value moved from another profile or key")
+	}
+
+	return string(plaintext), nil
+}
+
+// additional returns the additional data binding a value to its profile and key.
+// Both are length-prefixed, as profile names may contain any separator.
+func additional(profile, name string) []byte {
+	data := binary.AppendUvarint(nil, uint64(len(profile)))
+	data = append(data, profile...)
+	data = binary.AppendUvarint(data, uint64(len(name)))
+
+	return append(data, name...)
+}
+
+// aead returns the AES-GCM cipher for the key.
+func (k Key) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Decrypt decrypts the value if it is encrypted, loading the key on first use.
+// Unencrypted values are returned as is.
+func Decrypt(profile, name, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	key, err := loadOnce()
+	if err != nil {
+		return "", err
+	}
+
+	return key.Decrypt(profile, name, value)
+}
@@ -13,6 +13,7 @@ Use "envprof --help" for more information.
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -25,9 +26,16 @@ var version = "unknown - unofficial & generated by unknown"
 // main is the entry point of the application.
 func main() {
 	if err := cli.Execute(version); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		var exit cli.ExitError
+		if !errors.As(err, &exit) {
+			exit = cli.ExitError{Code: 1, Err: err}
+		}
 
-		os.Exit(1)
+		if exit.Err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+
+		os.Exit(exit.Code)
 	}
 
 	os.Exit(0)
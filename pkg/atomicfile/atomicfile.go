@@ -0,0 +1,90 @@
+package atomicfile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Write writes data to path with the given permissions.
+// The data is written to a temporary file in the same directory, which then replaces path,
+// so that readers observe either the old or the new content, never a partial write.
+// If path is a symbolic link, the file it points to is replaced instead of the link.
+func Write(path string, data []byte, perm os.FileMode) (err error) {
+	path, err = resolve(path)
+	if err != nil {
+		return err
+	}
+
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file for %q: %w", path, err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("writing temporary file for %q: %w", path, err)
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("syncing temporary file for %q: %w", path, err)
+	}
+
+	if err = tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("setting permissions for %q: %w", path, err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("closing temporary file for %q: %w", path, err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// maxLinks is the maximum number of symbolic links followed, as for path resolution on Linux.
+const maxLinks = 40
+
+// resolve follows the symbolic links at path, returning the path of the file they point to,
+// which may not exist yet.
+func resolve(path string) (string, error) {
+	for range maxLinks {
+		info, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && info.Mode()&fs.ModeSymlink == 0) {
+			return path, nil
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("checking %q: %w", path, err)
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", fmt.Errorf("reading link %q: %w", path, err)
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+
+		path = target
+	}
+
+	return "", fmt.Errorf("resolving %q: too many levels of symbolic links", path)
+}
@@ -0,0 +1,2 @@
+// Package atomicfile writes files atomically by renaming a fully written temporary file into place.
+package atomicfile
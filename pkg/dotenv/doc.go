@@ -0,0 +1,22 @@
+/*
+Package dotenv reads and writes dotenv files.
+
+The supported dialect is line based:
+
+  - Empty lines and lines starting with '#' are ignored.
+  - Each assignment has the form KEY=VALUE, optionally prefixed with 'export '.
+    Whitespace around the key and the '=' is ignored.
+  - Keys start with a letter or '_', followed by letters, digits, '_', '.' or '-'.
+  - Unquoted values run until the end of the line. A '#' preceded by whitespace starts a comment,
+    and surrounding whitespace is trimmed.
+  - Single-quoted ('...') and backtick-quoted (`...`) values are literal and may span multiple lines.
+  - Double-quoted ("...") values may span multiple lines and support the escape sequences
+    \n, \r, \t, \", \\ and \$. Any other backslash is kept as is.
+  - Only whitespace or a comment may follow a closing quote.
+  - Unquoted and double-quoted values expand $VAR, ${VAR}, ${VAR:-default} (unset or empty)
+    and ${VAR-default} (unset). Variables are looked up among the preceding assignments of the same file,
+    then through an optional lookup function. Unresolved variables expand to the empty string.
+
+Values written by Quote (and therefore Marshal) read back identically through Parse.
+*/
+package dotenv
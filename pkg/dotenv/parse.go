@@ -0,0 +1,413 @@
+package dotenv
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// Lookup resolves variables that are not assigned in the dotenv content itself.
+type Lookup func(key string) (string, bool)
+
+// Read parses the dotenv file at path.
+func Read(path string, lookup Lookup) (env.Env, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading dotenv file %q: %w", path, err)
+	}
+
+	env, err := Parse(data, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("parsing dotenv file %q: %w", path, err)
+	}
+
+	return env, nil
+}
+
+// Parse parses dotenv content according to the dialect described in the package documentation.
+// lookup may be nil.
+func Parse(data []byte, lookup Lookup) (env.Env, error) {
+	p := parser{
+		src:    []rune(strings.ReplaceAll(string(data), "\r\n", "\n")),
+		line:   1,
+		env:    make(env.Env),
+		lookup: lookup,
+	}
+
+	for {
+		p.skipBlank()
+
+		if p.eof() {
+			return p.env, nil
+		}
+
+		line := p.line
+
+		key, value, err := p.assignment()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		p.env[key] = value
+	}
+}
+
+// parser holds the state while parsing dotenv content.
+type parser struct {
+	src    []rune
+	pos    int
+	line   int
+	env    env.Env
+	lookup Lookup
+}
+
+// eof reports whether the whole input has been consumed.
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// peek returns the current rune, or 0 at the end of the input.
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+
+	return p.src[p.pos]
+}
+
+// next consumes and returns the current rune, keeping track of line numbers.
+func (p *parser) next() rune {
+	r := p.src[p.pos]
+
+	p.pos++
+
+	if r == '\n' {
+		p.line++
+	}
+
+	return r
+}
+
+// skipSpaces skips spaces and tabs on the current line.
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+// skipLine skips the rest of the current line, including the newline.
+func (p *parser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+// skipBlank skips whitespace, empty lines and comment lines.
+func (p *parser) skipBlank() {
+	for !p.eof() {
+		switch r := p.peek(); {
+		case r == '#':
+			p.skipLine()
+		case unicode.IsSpace(r):
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+// word reads until whitespace, '=' or the end of the input.
+func (p *parser) word() string {
+	start := p.pos
+
+	for !p.eof() && p.peek() != '=' && !unicode.IsSpace(p.peek()) {
+		p.next()
+	}
+
+	return string(p.src[start:p.pos])
+}
+
+// assignment parses a single KEY=VALUE assignment.
+func (p *parser) assignment() (key, value string, err error) {
+	key = p.word()
+
+	if key == "export" && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpaces()
+
+		key = p.word()
+	}
+
+	if !ValidKey(key) {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+
+	p.skipSpaces()
+
+	if p.eof() || p.next() != '=' {
+		return "", "", fmt.Errorf("expected '=' after key %q", key)
+	}
+
+	p.skipSpaces()
+
+	switch p.peek() {
+	case '\'', '`':
+		value, err = p.literal()
+	case '"':
+		value, err = p.double()
+	default:
+		return key, p.unquoted(), nil
+	}
+
+	if err != nil {
+		return "", "", fmt.Errorf("key %q: %w", key, err)
+	}
+
+	p.skipSpaces()
+
+	switch {
+	case p.eof():
+	case p.peek() == '#':
+		p.skipLine()
+	case p.peek() == '\n':
+		p.next()
+	default:
+		return "", "", fmt.Errorf("key %q: unexpected characters after closing quote", key)
+	}
+
+	return key, value, nil
+}
+
+// unquoted reads an unquoted value until the end of the line or an inline comment.
+func (p *parser) unquoted() string {
+	start := p.pos
+
+	for !p.eof() && p.peek() != '\n' {
+		if p.peek() == '#' && p.pos > 0 && unicode.IsSpace(p.src[p.pos-1]) {
+			break
+		}
+
+		p.next()
+	}
+
+	raw := []rune(strings.TrimRightFunc(string(p.src[start:p.pos]), unicode.IsSpace))
+
+	p.skipLine()
+
+	var builder strings.Builder
+
+	for i := 0; i < len(raw); {
+		if raw[i] == '$' {
+			value, n := p.expand(raw[i:])
+
+			builder.WriteString(value)
+
+			i += n
+
+			continue
+		}
+
+		builder.WriteRune(raw[i])
+
+		i++
+	}
+
+	return builder.String()
+}
+
+// literal reads a single- or backtick-quoted value verbatim.
+func (p *parser) literal() (string, error) {
+	quote := p.next()
+	start := p.pos
+
+	for !p.eof() {
+		if p.peek() == quote {
+			value := string(p.src[start:p.pos])
+
+			p.next()
+
+			return value, nil
+		}
+
+		p.next()
+	}
+
+	return "", fmt.Errorf("unterminated quoted value, missing closing %c", quote)
+}
+
+// double reads a double-quoted value, resolving escape sequences and variables.
+func (p *parser) double() (string, error) {
+	p.next()
+
+	var builder strings.Builder
+
+	for !p.eof() {
+		switch r := p.peek(); r {
+		case '"':
+			p.next()
+
+			return builder.String(), nil
+		case '\\':
+			p.next()
+
+			if p.eof() {
+				builder.WriteRune(r)
+
+				continue
+			}
+
+			builder.WriteString(unescape(p.next()))
+		case '$':
+			value, n := p.expand(p.src[p.pos:])
+
+			for range n {
+				p.next()
+			}
+
+			builder.WriteString(value)
+		default:
+			builder.WriteRune(p.next())
+		}
+	}
+
+	return "", fmt.Errorf("unterminated quoted value, missing closing %c", '"')
+}
+
+// unescape returns the value of a backslash followed by r inside double quotes.
+func unescape(r rune) string {
+	switch r {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$':
+		return string(r)
+	default:
+		return `\` + string(r)
+	}
+}
+
+// expand resolves the variable reference at the start of src (which begins with '$'),
+// returning its value and the number of runes consumed.
+// References that are not well-formed are kept as a literal '$'.
+func (p *parser) expand(src []rune) (string, int) {
+	if len(src) > 1 && src[1] == '{' {
+		end := -1
+
+		for i := 2; i < len(src); i++ {
+			if src[i] == '}' {
+				end = i
+
+				break
+			}
+		}
+
+		if end < 0 {
+			return "$", 1
+		}
+
+		name, fallback, mode := splitReference(string(src[2:end]))
+
+		if !validName(name) {
+			return "$", 1
+		}
+
+		value, ok := p.resolve(name)
+
+		if (mode == ":-" && value == "") || (mode == "-" && !ok) {
+			value = fallback
+		}
+
+		return value, end + 1
+	}
+
+	end := 1
+
+	for end < len(src) && isNameRune(src[end], end == 1) {
+		end++
+	}
+
+	if end == 1 {
+		return "$", 1
+	}
+
+	value, _ := p.resolve(string(src[1:end]))
+
+	return value, end
+}
+
+// splitReference splits the content of a braced reference into its name, default value and operator.
+func splitReference(reference string) (name, fallback, mode string) {
+	for i, r := range reference {
+		if r != ':' && r != '-' {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(reference[i:], ":-"):
+			return reference[:i], reference[i+2:], ":-"
+		case r == '-':
+			return reference[:i], reference[i+1:], "-"
+		}
+	}
+
+	return reference, "", ""
+}
+
+// resolve looks up a variable among the preceding assignments, then through the lookup function.
+func (p *parser) resolve(name string) (string, bool) {
+	if p.env.Exists(name) {
+		return p.env.Get(name), true
+	}
+
+	if p.lookup != nil {
+		return p.lookup(name)
+	}
+
+	return "", false
+}
+
+// ValidKey reports whether key is a valid dotenv key.
+func ValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i, r := range key {
+		if !isNameRune(r, i == 0) && (i == 0 || (r != '.' && r != '-')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validName reports whether name is a valid variable name for expansion.
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		if !isNameRune(r, i == 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isNameRune reports whether r may appear in a variable name, at the first position if first is set.
+func isNameRune(r rune, first bool) bool {
+	switch {
+	case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return true
+	case r >= '0' && r <= '9':
+		return !first
+	default:
+		return false
+	}
+}
@@ -0,0 +1,228 @@
+package dotenv_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/idelchi/godyl/pkg/env"
+
+	"github.com/idelchi/envprof/pkg/dotenv"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	lookup := func(key string) (string, bool) {
+		switch key {
+		case "HOME":
+			return "/home/user", true
+		case "EMPTY":
+			return "", true
+		default:
+			return "", false
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  env.Env
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  env.Env{},
+		},
+		{
+			name:  "unquoted",
+			input: "A=1\nB = two words  \nC=",
+			want:  env.Env{"A": "1", "B": "two words", "C": ""},
+		},
+		{
+			name:  "key characters",
+			input: "_A.b-c=1\nA2=2",
+			want:  env.Env{"_A.b-c": "1", "A2": "2"},
+		},
+		{
+			name:  "export prefix",
+			input: "export A=1\nexport\tB='2'\nexport=3",
+			want:  env.Env{"A": "1", "B": "2", "export": "3"},
+		},
+		{
+			name:  "comments",
+			input: "# comment\n  # indented\nA=1 # trailing\nB=a#b\nC='1' # after quote\nD=\"2\"#tight",
+			want:  env.Env{"A": "1", "B": "a#b", "C": "1", "D": "2"},
+		},
+		{
+			name:  "blank lines and CRLF",
+			input: "\r\n\nA=1\r\n\r\nB='2'\r\n",
+			want:  env.Env{"A": "1", "B": "2"},
+		},
+		{
+			name:  "single quotes are literal",
+			input: `A='$HOME \n "x" # not a comment'`,
+			want:  env.Env{"A": `$HOME \n "x" # not a comment`},
+		},
+		{
+			name:  "backticks are literal",
+			input: "A=`it's $HOME`",
+			want:  env.Env{"A": "it's $HOME"},
+		},
+		{
+			name:  "double quote escapes",
+			input: `A="a\nb\rc\td \"q\" \\ \$HOME \x"`,
+			want:  env.Env{"A": "a\nb\rc\td \"q\" \\ $HOME \\x"},
+		},
+		{
+			name:  "multi-line values",
+			input: "A='line 1\nline 2'\nB=\"x\ny\"\nC=`1\n\n2`\nD=after",
+			want:  env.Env{"A": "line 1\nline 2", "B": "x\ny", "C": "1\n\n2", "D": "after"},
+		},
+		{
+			name:  "expansion from preceding assignments",
+			input: "A=1\nB=$A-${A}\nC=\"$B!\"\nD='$A'",
+			want:  env.Env{"A": "1", "B": "1-1", "C": "1-1!", "D": "$A"},
+		},
+		{
+			name:  "expansion through lookup",
+			input: "PATH=$HOME/bin:$PATH\nHOME=/override\nA=$HOME",
+			want:  env.Env{"PATH": "/home/user/bin:", "HOME": "/override", "A": "/override"},
+		},
+		{
+			name:  "defaults",
+			input: "A=${MISSING:-x}\nB=${MISSING-y}\nC=${EMPTY:-z}\nD=${EMPTY-w}\nE=\"${HOME:-v}\"",
+			want:  env.Env{"A": "x", "B": "y", "C": "z", "D": "", "E": "/home/user"},
+		},
+		{
+			name:  "malformed references are literal",
+			input: "A=$\nB=${\nC=${1X}\nD=cost $5\nE=\"a$\"",
+			want:  env.Env{"A": "$", "B": "${", "C": "${1X}", "D": "cost $5", "E": "a$"},
+		},
+		{
+			name:  "later assignments win",
+			input: "A=1\nA=2",
+			want:  env.Env{"A": "2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := dotenv.Parse([]byte(test.input), lookup)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", test.input, err)
+			}
+
+			if !maps.Equal(got, test.want) {
+				t.Errorf("Parse(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "missing equals",
+			input: "A",
+			want:  `line 1: expected '=' after key "A"`,
+		},
+		{
+			name:  "invalid key",
+			input: "A=1\n1A=2",
+			want:  `line 2: invalid key "1A"`,
+		},
+		{
+			name:  "unterminated single quote",
+			input: "A='open\nB=1",
+			want:  `line 1: key "A": unterminated quoted value, missing closing '`,
+		},
+		{
+			name:  "unterminated double quote",
+			input: `A="open`,
+			want:  `line 1: key "A": unterminated quoted value, missing closing "`,
+		},
+		{
+			name:  "characters after closing quote",
+			input: "A='1'2",
+			want:  `line 1: key "A": unexpected characters after closing quote`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := dotenv.Parse([]byte(test.input), nil)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error %q", test.input, test.want)
+			}
+
+			if err.Error() != test.want {
+				t.Errorf("Parse(%q) error = %q, want %q", test.input, err, test.want)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		env  env.Env
+	}{
+		{
+			name: "bare",
+			env:  env.Env{"A": "1", "B": "/usr/local/bin:/usr/bin", "C": "user@host", "D": ""},
+		},
+		{
+			name: "single-quoted",
+			env:  env.Env{"A": "two words", "B": "$HOME", "C": `a "quoted" \ value`, "D": "# not a comment"},
+		},
+		{
+			name: "double-quoted",
+			env:  env.Env{"A": "it's", "B": "it's $HOME", "C": `it's "\"`, "D": "it's\na\r\tb"},
+		},
+		{
+			name: "multi-line",
+			env:  env.Env{"A": "line 1\nline 2", "B": "\n", "C": "trailing\n"},
+		},
+		{
+			name: "carriage returns",
+			env:  env.Env{"A": "a\r\nb", "B": "\r", "C": "line 1\r\nline 2\r\n"},
+		},
+		{
+			name: "whitespace",
+			env:  env.Env{"A": " leading", "B": "trailing ", "C": "\t"},
+		},
+		{
+			name: "unicode",
+			env:  env.Env{"A": "héllo wörld", "B": "日本"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			marshaled := dotenv.Marshal(test.env)
+
+			got, err := dotenv.Parse([]byte(marshaled), nil)
+			if err != nil {
+				t.Fatalf("Parse(Marshal(%q)) returned error: %v\n%s", test.env, err, marshaled)
+			}
+
+			if !maps.Equal(got, test.env) {
+				t.Errorf("Parse(Marshal(%q)) = %q\n%s", test.env, got, marshaled)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package dotenv
+
+import (
+	"strings"
+
+	"github.com/idelchi/godyl/pkg/env"
+)
+
+// Marshal renders the environment as dotenv lines, sorted by key.
+func Marshal(env env.Env) string {
+	lines := make([]string, 0, len(env))
+
+	for _, key := range env.Keys() {
+		lines = append(lines, Line(key, env.Get(key)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Line renders a single KEY=VALUE assignment.
+func Line(key, value string) string {
+	return key + "=" + Quote(value)
+}
+
+// Quote returns value in a form that Parse reads back unchanged.
+//   - Values consisting only of safe characters are left bare.
+//   - Values without single quotes or carriage returns are single-quoted.
+//   - All other values are double-quoted, escaping '\', '"', '$' and line breaks,
+//     as Parse reads "\r\n" as "\n" outside escapes.
+func Quote(value string) string {
+	switch {
+	case isBare(value):
+		return value
+	case !strings.ContainsAny(value, "'\r"):
+		return "'" + value + "'"
+	default:
+		return `"` + escaper.Replace(value) + `"`
+	}
+}
+
+// escaper escapes the characters with a special meaning inside double quotes.
+var escaper = strings.NewReplacer( //nolint:gochecknoglobals // Stateless replacer.
+	`\`, `\\`,
+	`"`, `\"`,
+	`$`, `\$`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// isBare reports whether value can be written without quotes.
+func isBare(value string) bool {
+	for _, r := range value {
+		switch {
+		case isNameRune(r, false):
+		case strings.ContainsRune("-./:,@+%", r):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
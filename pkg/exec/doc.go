@@ -1,2 +1,3 @@
-// Package execx provides a cross-platform process replacement primitive.
+// Package execx provides a cross-platform process replacement primitive,
+// and running commands as child processes with signal forwarding.
 package execx
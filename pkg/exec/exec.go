@@ -1,8 +1,14 @@
 package execx
 
 import (
+	"context"
+	"errors"
+	"os"
 	"os/exec"
+	"os/signal"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/idelchi/envprof/pkg/terminal"
 
@@ -12,13 +18,95 @@ import (
 // Replace replaces the current process with command+args using env and dir.
 // On success it never returns.
 func Replace(command string, args, env []string, shell terminal.Shell) error {
+	path, args, err := resolve(command, args, shell)
+	if err != nil {
+		return err
+	}
+
+	return replace(path, args, env)
+}
+
+// KillDelay is how long a child process is given to exit after being terminated, before it is killed.
+const KillDelay = 5 * time.Second
+
+// Run runs command+args as a child process using env, connected to the standard streams,
+// and returns its exit code. While the child runs, termination signals are forwarded to it,
+// as are signals generated by the terminal, unless envprof is in the foreground of the terminal,
+// which then sends them to the child itself.
+// When ctx is done, the child is terminated, and killed if it has not exited after KillDelay.
+func Run(ctx context.Context, command string, args, env []string, shell terminal.Shell) (int, error) {
+	path, args, err := resolve(command, args, shell)
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := Command(ctx, path, args...)
+
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	signals := make(chan os.Signal, 1)
+
+	signal.Notify(signals, slices.Concat(intercepted, forwarded)...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if slices.Contains(forwarded, sig) || !foreground() {
+					forward(cmd.Process, sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) && (ctx.Err() == nil || cmd.ProcessState == nil) {
+			return 0, err
+		}
+	}
+
+	return exitCode(cmd.ProcessState), nil
+}
+
+// Command returns the command to run path with args, which is terminated when ctx is done,
+// and killed if it has not exited after KillDelay.
+func Command(ctx context.Context, path string, args ...string) *exec.Cmd {
+	cmd := build(ctx, path, args)
+
+	cmd.Cancel = func() error { return terminate(cmd.Process) }
+	cmd.WaitDelay = KillDelay
+
+	return cmd
+}
+
+// Script runs a shell script as a child process using env, with "sh -c" on Unix and "cmd /c" on Windows,
+// and returns its exit code.
+func Script(script string, env []string) (int, error) {
+	return Run(context.Background(), scriptShell[0], append(scriptShell[1:], script), env, "")
+}
+
+// resolve returns the path and arguments to execute,
+// looking up the command, or wrapping it into an invocation of the interactive shell.
+func resolve(command string, args []string, shell terminal.Shell) (string, []string, error) {
 	if !shell.Interactive() {
 		path, err := exec.LookPath(command)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
 
-		return replace(path, args, env)
+		return path, args, nil
 	}
 
 	switch shell.Type() {
@@ -46,7 +134,7 @@ func Replace(command string, args, env []string, shell terminal.Shell) error {
 		args = []string{"-c", cmd}
 	}
 
-	return replace(string(shell), args, env)
+	return string(shell), args, nil
 }
 
 // quoteArg quotes a string for safe use in shell commands.
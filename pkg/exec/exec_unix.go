@@ -3,9 +3,31 @@
 package execx
 
 import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+
 	"golang.org/x/sys/unix"
 )
 
+// intercepted are the signals generated by the terminal, which it sends to its foreground process group,
+// the child process included. They are only forwarded if envprof is not in the foreground,
+// so that the child does not receive them twice.
+//
+//nolint:gochecknoglobals	// Platform-specific constant list.
+var intercepted = []os.Signal{unix.SIGINT, unix.SIGQUIT, unix.SIGWINCH}
+
+// forwarded are the signals sent to envprof alone, which are forwarded to child processes.
+//
+//nolint:gochecknoglobals	// Platform-specific constant list.
+var forwarded = []os.Signal{unix.SIGTERM, unix.SIGHUP}
+
+// scriptShell is the shell invocation used to run scripts.
+//
+//nolint:gochecknoglobals	// Platform-specific constant list.
+var scriptShell = []string{"sh", "-c"}
+
 // replace replaces the current process with the specified command using unix.Exec.
 // On success it never returns.
 func replace(path string, args, env []string) error {
@@ -13,3 +35,45 @@ func replace(path string, args, env []string) error {
 
 	return unix.Exec(path, argv, env)
 }
+
+// build creates the command for the given path and arguments.
+func build(ctx context.Context, path string, args []string) *exec.Cmd {
+	//nolint:gosec	// The user can execute whatever they'd like.
+	return exec.CommandContext(ctx, path, args...)
+}
+
+// terminate asks the child process to exit.
+func terminate(process *os.Process) error {
+	return process.Signal(unix.SIGTERM)
+}
+
+// forward sends the signal to the child process.
+func forward(process *os.Process, sig os.Signal) {
+	_ = process.Signal(sig)
+}
+
+// foreground reports whether envprof is in the foreground process group of its controlling terminal,
+// which then sends the signals it generates to the child process as well.
+func foreground() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+
+	defer tty.Close()
+
+	group, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+
+	return err == nil && group == unix.Getpgrp()
+}
+
+// exitCode returns the exit code of the process, following the shell convention of 128+n
+// for processes terminated by signal n.
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		//nolint:mnd	// Shell convention for signal exit codes.
+		return 128 + int(status.Signal())
+	}
+
+	return state.ExitCode()
+}
@@ -4,7 +4,6 @@ package execx
 
 import (
 	"context"
-	"errors"
 	"os"
 	"os/exec"
 	"strings"
@@ -12,39 +11,63 @@ import (
 	"github.com/idelchi/godyl/pkg/path/file"
 )
 
+// intercepted are the signals caught and dropped while child processes run.
+// Console control events reach the child directly, so they only need to be kept from terminating envprof.
+//
+//nolint:gochecknoglobals	// Platform-specific constant list.
+var intercepted = []os.Signal{os.Interrupt}
+
+// forwarded are the signals forwarded to child processes, none on Windows.
+//
+//nolint:gochecknoglobals	// Platform-specific constant list.
+var forwarded []os.Signal
+
+// scriptShell is the shell invocation used to run scripts.
+//
+//nolint:gochecknoglobals	// Platform-specific constant list.
+var scriptShell = []string{"cmd", "/c"}
+
 // replace simulates process replacement on Windows by running the command and exiting with its code.
 func replace(path string, args, env []string) error {
-	var command *exec.Cmd
+	code, err := Run(context.Background(), path, args, env, "")
+	if err != nil {
+		return err
+	}
+
+	os.Exit(code) //nolint:forbidigo	// Allowing exit code propagation.
+
+	return nil
+}
 
+// build creates the command for the given path and arguments, running batch files through cmd.exe.
+func build(ctx context.Context, path string, args []string) *exec.Cmd {
 	ext := strings.ToLower(file.New(path).Extension())
 	if ext == "bat" || ext == "cmd" {
 		//nolint:gosec	// The user can execute whatever they'd like.
-		command = exec.CommandContext(
-			context.Background(),
+		return exec.CommandContext(
+			ctx,
 			"cmd.exe",
 			append([]string{"/c", path}, args...)...)
-	} else {
-		command = exec.CommandContext(context.Background(), path, args...)
 	}
 
-	command.Env = env
-
-	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
-
-	if err := command.Run(); err != nil {
-		var ee *exec.ExitError
-		if errors.As(err, &ee) {
-			os.Exit(ee.ExitCode()) //nolint:forbidigo // Allowing exit code propagation.
-		}
+	//nolint:gosec	// The user can execute whatever they'd like.
+	return exec.CommandContext(ctx, path, args...)
+}
 
-		return err
-	}
+// terminate kills the child process, as Windows has no signal to ask it to exit.
+func terminate(process *os.Process) error {
+	return process.Kill()
+}
 
-	if ps := command.ProcessState; ps != nil {
-		os.Exit(ps.ExitCode()) //nolint:forbidigo	// Allowing exit code propagation.
-	}
+// forward does nothing, as the child receives console control events itself.
+func forward(_ *os.Process, _ os.Signal) {}
 
-	os.Exit(0) //nolint:forbidigo	// Allowing exit code propagation.
+// foreground reports true, as console control events always reach the child process directly.
+func foreground() bool {
+	return true
+}
 
-	return nil
+// exitCode returns the exit code of the process.
+func exitCode(state *os.ProcessState) int {
+	return state.ExitCode()
 }
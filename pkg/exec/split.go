@@ -0,0 +1,49 @@
+package execx
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Split parses input as a single simple shell command and returns its words,
+// with quotes removed, parameters expanded using env, and braces and globs expanded as by bash.
+// Globs are matched relative to the current working directory and kept as is if nothing matches.
+// It reports false if the input is anything else, such as several commands, pipelines, redirections,
+// assignments, command substitutions or invalid syntax, which need to be run as a script by a shell instead.
+func Split(input string, env []string) ([]string, bool, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(input), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return nil, false, nil //nolint:nilerr	// Left to the shell to interpret.
+	}
+
+	stmt := file.Stmts[0]
+
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 || len(call.Assigns) > 0 || len(stmt.Redirs) > 0 ||
+		stmt.Negated || stmt.Background || stmt.Coprocess {
+		return nil, false, nil
+	}
+
+	// Globs are matched relative to PWD, which must be the actual working directory of the command.
+	if dir, err := os.Getwd(); err == nil {
+		env = append(slices.Clone(env), "PWD="+dir)
+	}
+
+	config := &expand.Config{Env: expand.ListEnviron(env...), ReadDir2: os.ReadDir}
+
+	fields, err := expand.Fields(config, call.Args...)
+	if err != nil {
+		if errors.As(err, new(expand.UnexpectedCommandError)) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	return fields, true, nil
+}
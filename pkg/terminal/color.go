@@ -0,0 +1,72 @@
+package terminal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Color is a basic terminal color, supported by all shells.
+type Color string
+
+const (
+	// Black is the color black.
+	Black Color = "black"
+	// Red is the color red.
+	Red Color = "red"
+	// Green is the color green.
+	Green Color = "green"
+	// Yellow is the color yellow.
+	Yellow Color = "yellow"
+	// Blue is the color blue.
+	Blue Color = "blue"
+	// Magenta is the color magenta.
+	Magenta Color = "magenta"
+	// Cyan is the color cyan.
+	Cyan Color = "cyan"
+	// White is the color white.
+	White Color = "white"
+)
+
+// Colors returns all supported colors.
+func Colors() []Color {
+	return []Color{Black, Red, Green, Yellow, Blue, Magenta, Cyan, White}
+}
+
+// Valid checks that the color is supported. The empty color is valid and means "no color".
+func (c Color) Valid() error {
+	if c == "" || slices.Contains(Colors(), c) {
+		return nil
+	}
+
+	return fmt.Errorf("unsupported color %q, must be one of %v", c, Colors())
+}
+
+// ANSI returns the ANSI escape sequence setting the color, or the empty string for no color.
+func (c Color) ANSI() string {
+	index := slices.Index(Colors(), c)
+	if index < 0 {
+		return ""
+	}
+
+	//nolint:mnd	// ANSI foreground colors start at 30.
+	return fmt.Sprintf("\x1b[%dm", 30+index)
+}
+
+// Paint wraps the text in the ANSI escape sequences for the color, if any.
+func (c Color) Paint(text string) string {
+	if c.ANSI() == "" {
+		return text
+	}
+
+	return c.ANSI() + text + "\x1b[0m"
+}
+
+// PowerShell returns the name of the color as a PowerShell 'ConsoleColor'.
+func (c Color) PowerShell() string {
+	if c == "" {
+		return ""
+	}
+
+	return strings.ToUpper(string(c[:1])) + string(c[1:])
+}
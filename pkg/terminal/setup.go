@@ -0,0 +1,276 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/idelchi/godyl/pkg/path/file"
+)
+
+// Setup customizes an interactive shell spawned with SpawnWith.
+type Setup struct {
+	// Prompt is shown in front of the shell's own prompt, if not empty.
+	Prompt string
+	// Color is the color of the prompt.
+	Color Color
+	// Init are commands run at startup, after the user's own startup files and the aliases.
+	Init []string
+	// Aliases maps alias names to the commands they expand to.
+	Aliases map[string]string
+}
+
+// Empty reports whether the setup customizes nothing.
+func (s Setup) Empty() bool {
+	return s.Prompt == "" && len(s.Init) == 0 && len(s.Aliases) == 0
+}
+
+// SpawnWith launches a new shell with the specified environment variables, customized by the setup,
+// and terminates it when ctx is done, as Spawn does.
+// Bash and zsh load generated startup files that source the user's own ones first,
+// fish and PowerShell run startup commands, cmd runs 'doskey' and the commands,
+// and other shells load a generated file through the ENV variable, and get their prompt variable set.
+func SpawnWith(ctx context.Context, shell string, env []string, setup Setup) error {
+	if setup.Empty() {
+		return Spawn(ctx, shell, env)
+	}
+
+	dir, err := os.MkdirTemp("", "envprof-shell-")
+	if err != nil {
+		return fmt.Errorf("creating startup files: %w", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	args, env, err := setup.prepare(shell, env, dir)
+	if err != nil {
+		return fmt.Errorf("creating startup files: %w", err)
+	}
+
+	return spawn(ctx, shell, args, env)
+}
+
+// prepare returns the arguments and environment for the shell to apply the setup,
+// writing startup files into dir where needed.
+func (s Setup) prepare(shell string, env []string, dir string) ([]string, []string, error) {
+	switch name := file.New(shell).WithoutExtension().Base(); name {
+	case "bash":
+		rc := filepath.Join(dir, "bashrc")
+
+		content := "[ -f ~/.bashrc ] && . ~/.bashrc\n" + s.posix() + s.bash()
+
+		return []string{"--rcfile", rc}, env, os.WriteFile(rc, []byte(content), 0o600)
+	case "zsh":
+		// zsh reads its startup files from ZDOTDIR, which is restored once the user's files are loaded.
+		// If the user's .zshenv points ZDOTDIR elsewhere, that is where their other files are read from,
+		// and ZDOTDIR is pointed back to dir, so that the generated .zshrc is still loaded.
+		original := os.Getenv("ZDOTDIR")
+		if original == "" {
+			original, _ = os.UserHomeDir()
+		}
+
+		source := func(name string) string {
+			return fmt.Sprintf("[ -f \"$ENVPROF_ZDOTDIR/%[1]s\" ] && . \"$ENVPROF_ZDOTDIR/%[1]s\"\n", name)
+		}
+
+		files := map[string]string{
+			".zshenv": source(".zshenv") +
+				"if [ \"$ZDOTDIR\" != " + quote(dir) + " ]; then\n" +
+				"ENVPROF_ZDOTDIR=${ZDOTDIR:-$HOME}\nZDOTDIR=" + quote(dir) + "\n" +
+				"fi\n",
+			".zshrc": source(".zshrc") +
+				"ZDOTDIR=$ENVPROF_ZDOTDIR\nunset ENVPROF_ZDOTDIR\n" +
+				s.posix() + s.zsh(),
+		}
+
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		return nil, append(env, "ZDOTDIR="+dir, "ENVPROF_ZDOTDIR="+original), nil
+	case "fish":
+		return []string{"--init-command", s.fish()}, env, nil
+	case "pwsh", "powershell":
+		return []string{"-NoExit", "-Command", s.powershell()}, env, nil
+	case "cmd":
+		var args []string
+
+		if commands := s.cmdCommands(); len(commands) > 0 {
+			args = []string{"/K", strings.Join(commands, " & ")}
+		}
+
+		if s.Prompt != "" {
+			env = append(env, "PROMPT="+s.cmdPrompt())
+		}
+
+		return args, env, nil
+	default:
+		// Interactive POSIX shells source the file named by ENV.
+		rc := filepath.Join(dir, "env.sh")
+
+		content := "[ -n \"$ENVPROF_ENV\" ] && [ -f \"$ENVPROF_ENV\" ] && . \"$ENVPROF_ENV\"\n" +
+			"unset ENVPROF_ENV\n" +
+			s.posix()
+
+		env = append(env, "ENV="+rc, "ENVPROF_ENV="+os.Getenv("ENV"))
+
+		if s.Prompt != "" {
+			env = append(env, "PS1="+s.Prompt+" $ ")
+		}
+
+		return nil, env, os.WriteFile(rc, []byte(content), 0o600)
+	}
+}
+
+// aliases returns the names of the aliases, sorted.
+func (s Setup) aliases() []string {
+	return slices.Sorted(maps.Keys(s.Aliases))
+}
+
+// posix returns the commands defining the aliases and running the init commands in POSIX shells.
+func (s Setup) posix() string {
+	var builder strings.Builder
+
+	for _, name := range s.aliases() {
+		builder.WriteString("alias " + name + "=" + quote(s.Aliases[name]) + "\n")
+	}
+
+	for _, command := range s.Init {
+		builder.WriteString(command + "\n")
+	}
+
+	return builder.String()
+}
+
+// bash returns the bash commands setting the prompt, if any.
+func (s Setup) bash() string {
+	if s.Prompt == "" {
+		return ""
+	}
+
+	// PS1 is decoded for backslash escapes and then expanded as in double quotes,
+	// so the characters special to the expansion are escaped for it after the decoding.
+	prompt := strings.NewReplacer(`\`, `\\\\`, "$", `\\$`, "`", "\\\\`").Replace(s.Prompt)
+
+	if s.Color != "" {
+		prompt = `\[` + s.Color.ANSI() + `\]` + prompt + `\[` + "\x1b[0m" + `\]`
+	}
+
+	return "PS1=" + quote(prompt+" ") + `"$PS1"` + "\n"
+}
+
+// zsh returns the zsh commands setting the prompt, if any.
+func (s Setup) zsh() string {
+	if s.Prompt == "" {
+		return ""
+	}
+
+	prompt := strings.ReplaceAll(s.Prompt, "%", "%%")
+
+	if s.Color != "" {
+		prompt = "%F{" + string(s.Color) + "}" + prompt + "%f"
+	}
+
+	return "setopt PROMPT_PERCENT\nPROMPT=" + quote(prompt+" ") + `"$PROMPT"` + "\n"
+}
+
+// fish returns the fish commands applying the setup.
+func (s Setup) fish() string {
+	escape := strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace
+
+	var lines []string
+
+	for _, name := range s.aliases() {
+		lines = append(lines, "alias "+name+" '"+escape(s.Aliases[name])+"'")
+	}
+
+	lines = append(lines, s.Init...)
+
+	if s.Prompt == "" {
+		return strings.Join(lines, "\n")
+	}
+
+	color := "normal"
+	if s.Color != "" {
+		color = string(s.Color)
+	}
+
+	lines = append(lines,
+		"functions --copy fish_prompt __envprof_fish_prompt",
+		"function fish_prompt",
+		"set -l envprof_status $status",
+		"set_color "+color,
+		"printf '%s ' '"+escape(s.Prompt)+"'",
+		"set_color normal",
+		"__envprof_restore_status $envprof_status",
+		"__envprof_fish_prompt",
+		"end",
+		"function __envprof_restore_status; return $argv[1]; end",
+	)
+
+	return strings.Join(lines, "\n")
+}
+
+// powershell returns the PowerShell commands applying the setup.
+// Aliases are defined as functions, since PowerShell aliases cannot include arguments.
+func (s Setup) powershell() string {
+	var lines []string
+
+	for _, name := range s.aliases() {
+		lines = append(lines, "function global:"+name+" { "+s.Aliases[name]+" @args }")
+	}
+
+	lines = append(lines, s.Init...)
+
+	if s.Prompt == "" {
+		return strings.Join(lines, "\n")
+	}
+
+	color := ""
+	if s.Color != "" {
+		color = " -ForegroundColor " + s.Color.PowerShell()
+	}
+
+	lines = append(lines,
+		"$global:__envprofPrompt = $function:prompt",
+		"function global:prompt {",
+		"Write-Host -NoNewline"+color+" '"+strings.ReplaceAll(s.Prompt, "'", "''")+" '",
+		"& $global:__envprofPrompt",
+		"}",
+	)
+
+	return strings.Join(lines, "\n")
+}
+
+// cmdCommands returns the cmd commands defining the aliases as 'doskey' macros and running the init commands.
+func (s Setup) cmdCommands() []string {
+	var commands []string
+
+	for _, name := range s.aliases() {
+		commands = append(commands, "doskey "+name+"="+s.Aliases[name]+" $*")
+	}
+
+	return append(commands, s.Init...)
+}
+
+// cmdPrompt returns the value of the PROMPT variable showing the prompt.
+func (s Setup) cmdPrompt() string {
+	prompt := strings.ReplaceAll(s.Prompt, "$", "$$")
+
+	if s.Color != "" {
+		prompt = strings.ReplaceAll(s.Color.ANSI(), "\x1b", "$E") + prompt + "$E[0m"
+	}
+
+	return prompt + " $P$G"
+}
+
+// quote quotes a string with single quotes for POSIX shells.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
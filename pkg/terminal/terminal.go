@@ -8,20 +8,32 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/idelchi/godyl/pkg/env"
 )
 
+// KillDelay is how long a shell is given to exit after being terminated, before it is killed.
+const KillDelay = 5 * time.Second
+
 // Spawn launches a new shell with the specified environment variables.
-func Spawn(shell string, env []string) error {
-	cmd := exec.CommandContext(context.Background(), shell)
+// When ctx is done, the shell is terminated, and killed if it has not exited after KillDelay.
+func Spawn(ctx context.Context, shell string, env []string) error {
+	return spawn(ctx, shell, nil, env)
+}
 
+// spawn launches a new shell with the arguments and environment variables.
+func spawn(ctx context.Context, shell string, args, env []string) error {
+	cmd := exec.CommandContext(ctx, shell, args...)
+
+	cmd.Cancel = func() error { return terminate(cmd.Process) }
+	cmd.WaitDelay = KillDelay
 	cmd.Env = env
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
 		return fmt.Errorf("spawning terminal %q: %w", shell, err)
 	}
 
@@ -50,6 +62,35 @@ func (s Shell) Interactive() bool {
 	return s.Type() != None
 }
 
+// Inline returns the arguments for the shell to run an inline script.
+// Interactive Unix shells load their startup files (e.g. for aliases).
+func (s Shell) Inline(script string, interactive bool) []string {
+	switch s.Type() {
+	case Powershell:
+		return []string{"-Command", script}
+	case Cmd:
+		return []string{"/C", script}
+	default:
+		if interactive {
+			return []string{"-i", "-c", script}
+		}
+
+		return []string{"-c", script}
+	}
+}
+
+// File returns the arguments for the shell to run a script file with arguments.
+func (s Shell) File(path string, args []string) []string {
+	switch s.Type() {
+	case Powershell:
+		return append([]string{"-File", path}, args...)
+	case Cmd:
+		return append([]string{"/C", path}, args...)
+	default:
+		return append([]string{path}, args...)
+	}
+}
+
 // Type represents the type of shell.
 type Type int
 
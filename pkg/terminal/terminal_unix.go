@@ -0,0 +1,14 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminate hangs up the shell, which makes interactive shells exit.
+func terminate(process *os.Process) error {
+	return process.Signal(unix.SIGHUP)
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package terminal
+
+import (
+	"os"
+)
+
+// terminate kills the shell, as Windows has no signal to ask it to exit.
+func terminate(process *os.Process) error {
+	return process.Kill()
+}